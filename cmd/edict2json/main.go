@@ -0,0 +1,41 @@
+// Command edict2json converts a UTF-8 encoded EDICT2 file into the JSON
+// lines format embedded by the nihongo server.
+//
+//	edict2json < edict2.utf-8 | gzip > edict2.json.gz
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+
+	"github.com/gojp/nihongo/edict2"
+)
+
+func main() {
+	edict := edict2.NewText(os.Stdin)
+	w := bufio.NewWriter(os.Stdout)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	for edict.Scan() {
+		err := edict.NextEntry()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			log.Fatal("Could not parse entry: ", err)
+		}
+		if err := enc.Encode(edict.Entry()); err != nil {
+			log.Fatal("Could not encode entry: ", err)
+		}
+	}
+	if err := edict.Err(); err != nil {
+		log.Fatal("Could not read input: ", err)
+	}
+
+	if err := w.Flush(); err != nil {
+		log.Fatal("Could not write output: ", err)
+	}
+}
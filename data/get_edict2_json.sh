@@ -4,5 +4,5 @@ wget http://ftp.monash.edu.au/pub/nihongo/edict2.gz -O edict2.gz
 gunzip edict2.gz
 iconv -f EUC-JP -t UTF-8 < edict2 > edict2.utf-8
 rm edict2
-python edict2_parser.py > edict2.json
+go run ../cmd/edict2json < edict2.utf-8 > edict2.json
 gzip edict2.json
@@ -6,35 +6,68 @@ import (
 	"io"
 )
 
+// Token types understood by EDict.
+const (
+	// TokenJSON reads one JSON encoded Entry per line.
+	TokenJSON = iota
+	// TokenText reads raw, UTF-8 encoded EDICT2 lines.
+	TokenText
+)
+
 type EDict struct {
 	*bufio.Scanner
 	TokenType int
 	entry     *Entry
+
+	// entries parsed from the current EDICT2 line that have not been
+	// returned by NextEntry yet
+	pending []Entry
+	err     error
 }
 
 type Gloss struct {
-	Common  bool
-	English string
-	Field   *string
-	Related []string
-	Tags    []string
+	Common  bool     `json:"common"`
+	English string   `json:"english"`
+	Field   *string  `json:"field"`
+	Related []string `json:"related"`
+	Tags    []string `json:"tags"`
+
+	// The following are only available for entries read from JMdict, where
+	// they are recorded per sense.
+	Pos      []string     `json:"pos,omitempty"`
+	Dialects []string     `json:"dialects,omitempty"`
+	Antonyms []string     `json:"antonyms,omitempty"`
+	Sources  []LoanSource `json:"sources,omitempty"`
+}
+
+// LoanSource describes the foreign word a loanword was derived from.
+type LoanSource struct {
+	Lang     string `json:"lang"`
+	Original string `json:"original,omitempty"`
+	Partial  bool   `json:"partial,omitempty"`
+	Wasei    bool   `json:"wasei,omitempty"`
 }
 
 type Entry struct {
-	Common    bool
-	Dialects  []string
-	EntSeq    string `json:"ent_seq"`
-	Fields    []string
-	Furigana  string
-	Glosses   []Gloss
-	HasAudio  bool
-	Japanese  string
+	Common    bool     `json:"common"`
+	Dialects  []string `json:"dialects"`
+	EntSeq    string   `json:"ent_seq"`
+	Fields    []string `json:"fields"`
+	Furigana  string   `json:"furigana"`
+	Glosses   []Gloss  `json:"glosses"`
+	HasAudio  bool     `json:"has_audio"`
+	Japanese  string   `json:"japanese"`
 	KanaTags  []string `json:"kana_tags"`
 	KanjiTags []string `json:"kanji_tags"`
-	Pos       []string
-	Tags      []string
+	Pos       []string `json:"pos"`
+	Tags      []string `json:"tags"`
+
+	// Priority holds the JMdict ke_pri and re_pri codes, such as news1 or
+	// nf12, of the kanji and reading that make up this entry.
+	Priority []string `json:"priority,omitempty"`
 }
 
+// New returns an EDict reading JSON encoded entries from r.
 func New(r io.Reader) *EDict {
 	s := bufio.NewScanner(r)
 	edict := &EDict{
@@ -43,7 +76,51 @@ func New(r io.Reader) *EDict {
 	return edict
 }
 
+// NewText returns an EDict reading raw EDICT2 lines from r. The input must
+// already be converted from EUC-JP to UTF-8.
+func NewText(r io.Reader) *EDict {
+	edict := New(r)
+	edict.TokenType = TokenText
+	return edict
+}
+
+// Scan advances to the next line of the input. Raw EDICT2 lines may hold
+// several entries, one for each spelling and reading, which NextEntry then
+// returns in turn; the header line and blank lines are skipped.
+func (edict *EDict) Scan() bool {
+	if edict.TokenType != TokenText {
+		return edict.Scanner.Scan()
+	}
+
+	if len(edict.pending) > 0 {
+		return true
+	}
+	for edict.Scanner.Scan() {
+		line := edict.Text()
+		if line == "" || isHeader(line) {
+			continue
+		}
+		edict.pending, edict.err = ParseLine(line)
+		if edict.err != nil || len(edict.pending) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func (edict *EDict) NextEntry() error {
+	if edict.TokenType == TokenText {
+		if edict.err != nil {
+			return edict.err
+		}
+		if len(edict.pending) == 0 {
+			return io.EOF
+		}
+		edict.entry = &edict.pending[0]
+		edict.pending = edict.pending[1:]
+		return nil
+	}
+
 	e, err := parseEntry(edict.Bytes())
 	if err != nil {
 		return err
@@ -0,0 +1,241 @@
+package edict2
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// EDICT2 FORMAT:
+//
+//    KANJI-1;KANJI-2 [KANA-1;KANA-2] /(general information) (see xxxx) gloss/gloss/.../
+//    垜;安土;堋 [あずち] /(n) mound on which targets are placed (in archery)/firing mound/EntL2542010/
+
+// Part of speech codes
+var posCodes = []string{
+	"adj-i", "adj-na", "adj-no", "adj-pn", "adj-t", "adj-f", "adj",
+	"adv", "adv-to", "aux", "aux-v", "aux-adj", "conj", "ctr", "exp",
+	"int", "iv", "n", "n-adv", "n-suf", "n-pref", "n-t", "num", "pn",
+	"pref", "prt", "suf", "v1", "v2a-s", "v4h", "v4r", "v5", "v5aru",
+	"v5b", "v5g", "v5k", "v5k-s", "v5m", "v5n", "v5r", "v5r-i", "v5s",
+	"v5t", "v5u", "v5u-s", "v5uru", "v5z", "vz", "vi", "vk", "vn",
+	"vr", "vs", "vs-s", "vs-i", "vt",
+}
+
+// Field of application codes
+var fieldCodes = []string{
+	"Buddh", "MA", "comp", "food", "geom", "ling", "math", "mil",
+	"physics", "chem", "biol",
+}
+
+// Miscellaneous marking codes
+var miscCodes = []string{
+	"X", "abbr", "arch", "ateji", "chn", "col", "derog", "eK", "ek",
+	"fam", "fem", "gikun", "hon", "hum", "iK", "id", "ik", "io",
+	"m-sl", "male", "male-sl", "oK", "obs", "obsc", "ok", "on-mim",
+	"poet", "pol", "rare", "sens", "sl", "uK", "uk", "vulg", "P",
+}
+
+// Dialect codes
+var dialectCodes = []string{
+	"kyb", "osb", "ksb", "ktb", "tsb", "thb", "tsug", "kyu", "rkb",
+	"nab",
+}
+
+var (
+	reKana       = regexp.MustCompile(`^\[(.*)\]`)
+	reTags       = regexp.MustCompile(`\(((?:` + alternation(posCodes, miscCodes, dialectCodes) + `|[,]+)+):?\)`)
+	reFieldTags  = regexp.MustCompile(`\{(` + alternation(fieldCodes) + `)\}`)
+	reNumberTag  = regexp.MustCompile(`\((\d+)\)`)
+	reAnyTag     = regexp.MustCompile(`\(([^)]*)\)`)
+	reRelatedTag = regexp.MustCompile(`(?i)\(See ([^)]*)\)`)
+
+	posSet     = toSet(posCodes)
+	fieldSet   = toSet(fieldCodes)
+	miscSet    = toSet(miscCodes)
+	dialectSet = toSet(dialectCodes)
+)
+
+// ErrMalformedLine is returned by ParseLine for a line without a word and
+// glosses, or without the EntL sequence number that ends every EDICT2 line.
+var ErrMalformedLine = errors.New("edict2: line is not WORD [READING] /GLOSS/.../EntL.../")
+
+func alternation(codeSets ...[]string) string {
+	var quoted []string
+	for _, codes := range codeSets {
+		for _, c := range codes {
+			quoted = append(quoted, regexp.QuoteMeta(c))
+		}
+	}
+	return strings.Join(quoted, "|")
+}
+
+func toSet(codes []string) map[string]bool {
+	set := map[string]bool{}
+	for _, c := range codes {
+		set[c] = true
+	}
+	return set
+}
+
+func filterCodes(tags []string, set map[string]bool) []string {
+	var codes []string
+	for _, t := range tags {
+		if set[t] {
+			codes = append(codes, t)
+		}
+	}
+	return codes
+}
+
+// extractTags finds the first match of re in word, and returns word with
+// every match of re removed along with the comma-separated contents of the
+// first match.
+func extractTags(word string, re *regexp.Regexp) (string, []string) {
+	m := re.FindStringSubmatch(word)
+	if m == nil {
+		return word, nil
+	}
+	var tags []string
+	for _, group := range m[1:] {
+		tags = append(tags, strings.Split(group, ",")...)
+	}
+	return re.ReplaceAllString(word, ""), tags
+}
+
+type taggedWord struct {
+	word string
+	tags []string
+}
+
+func tagWords(words []string) []taggedWord {
+	tagged := make([]taggedWord, len(words))
+	for i, w := range words {
+		tagged[i].word, tagged[i].tags = extractTags(w, reTags)
+	}
+	return tagged
+}
+
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+// isHeader reports whether line is a comment or the EDICT2 file header,
+// neither of which hold dictionary entries.
+func isHeader(line string) bool {
+	return strings.HasPrefix(line, "#") || strings.HasPrefix(line, "　？？？")
+}
+
+// ParseLine parses a single line of a UTF-8 encoded EDICT2 file. Every
+// combination of kanji and kana alternatives on the line becomes its own
+// Entry, except where a kana reading is restricted to a subset of the kanji,
+// as in おくび(噯,噯気).
+func ParseLine(line string) ([]Entry, error) {
+	rawWords := strings.Split(line, " ")
+	rawFields := strings.Split(line, "/")
+	if len(rawWords) < 2 || len(rawFields) < 4 {
+		return nil, ErrMalformedLine
+	}
+
+	rawKanji := strings.Split(rawWords[0], ";")
+	rawKana := rawKanji
+	if m := reKana.FindStringSubmatch(rawWords[1]); m != nil {
+		rawKana = strings.Split(m[1], ";")
+	}
+
+	kanjiTagged := tagWords(rawKanji)
+	kanaTagged := tagWords(rawKana)
+
+	rawEnglish := rawFields[1 : len(rawFields)-2]
+	first, mainTags := extractTags(rawEnglish[0], reTags)
+	english := append([]string{first}, rawEnglish[1:]...)
+
+	if english[len(english)-1] == "(P)" {
+		if !contains(mainTags, "P") {
+			mainTags = append(mainTags, "P")
+		}
+		english = english[:len(english)-1]
+	}
+
+	// join numbered entries
+	var joined []string
+	hasNumbers := false
+	for _, e := range english {
+		clean, number := extractTags(e, reNumberTag)
+		clean = strings.TrimSpace(clean)
+		if number != nil {
+			hasNumbers = true
+			joined = append(joined, clean)
+		} else if hasNumbers {
+			joined[len(joined)-1] += "/" + clean
+		} else {
+			joined = append(joined, clean)
+		}
+	}
+
+	var glosses []Gloss
+	for _, g := range joined {
+		clean, related := extractTags(g, reRelatedTag)
+		clean, tags := extractTags(clean, reTags)
+		clean, fields := extractTags(clean, reFieldTags)
+
+		var field *string
+		if len(fields) > 0 {
+			field = &fields[0]
+		}
+
+		clean = strings.TrimSpace(clean)
+		if clean == "" {
+			continue
+		}
+		glosses = append(glosses, Gloss{
+			Common:  contains(tags, "P"),
+			English: clean,
+			Field:   field,
+			Related: related,
+			Tags:    tags,
+		})
+	}
+
+	entSeq := rawFields[len(rawFields)-2]
+	if !strings.HasPrefix(entSeq, "EntL") {
+		return nil, ErrMalformedLine
+	}
+
+	// EntL sequences that end in X have audio clips
+	hasAudio := strings.HasSuffix(entSeq, "X")
+	entSeq = strings.TrimSuffix(strings.TrimPrefix(entSeq, "EntL"), "X")
+
+	var entries []Entry
+	for _, kana := range kanaTagged {
+		// special case for kana like this: おくび(噯,噯気);あいき(噯気,噫気,噯木)
+		furigana, matchingKanji := extractTags(kana.word, reAnyTag)
+
+		for _, kanji := range kanjiTagged {
+			if matchingKanji != nil && !contains(matchingKanji, kanji.word) {
+				continue
+			}
+			entries = append(entries, Entry{
+				Common:    contains(mainTags, "P"),
+				Dialects:  filterCodes(mainTags, dialectSet),
+				EntSeq:    entSeq,
+				Fields:    filterCodes(mainTags, fieldSet),
+				Furigana:  furigana,
+				Glosses:   glosses,
+				HasAudio:  hasAudio,
+				Japanese:  kanji.word,
+				KanaTags:  kana.tags,
+				KanjiTags: kanji.tags,
+				Pos:       filterCodes(mainTags, posSet),
+				Tags:      filterCodes(mainTags, miscSet),
+			})
+		}
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,136 @@
+package edict2
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type pair struct {
+	japanese string
+	furigana string
+}
+
+var crossProductTests = []struct {
+	line string
+	want []pair
+}{
+	{
+		"垜;安土;堋 [あずち] /(n) mound on which targets are placed (in archery)/firing mound/EntL2542010/",
+		[]pair{{"垜", "あずち"}, {"安土", "あずち"}, {"堋", "あずち"}},
+	},
+	{
+		"噯;噯気;噫気;噯木 [おくび(噯,噯気);あいき(噯気,噫気,噯木)] /(n) belch/burp/eructation/EntL1639480/",
+		[]pair{{"噯", "おくび"}, {"噯気", "おくび"}, {"噯気", "あいき"}, {"噫気", "あいき"}, {"噯木", "あいき"}},
+	},
+	{
+		"むかつく /(v5k,vi) (1) to feel sick/(2) to be angry/EntL1012650X/",
+		[]pair{{"むかつく", "むかつく"}},
+	},
+}
+
+func TestParseLineCrossProduct(t *testing.T) {
+	for _, tt := range crossProductTests {
+		entries, err := ParseLine(tt.line)
+		if err != nil {
+			t.Fatalf("ParseLine(%q) returned error %v", tt.line, err)
+		}
+		var got []pair
+		for _, e := range entries {
+			got = append(got, pair{e.Japanese, e.Furigana})
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseLine(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParseLine(t *testing.T) {
+	line := "日本(P);日本国 [にほん(P);にっぽん] /(n) (1) Japan/(2) (See 和) Japanese/(P)/EntL1582710X/"
+	entries, err := ParseLine(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("len(entries) = %d, want %d", len(entries), 4)
+	}
+
+	e := entries[0]
+	if e.Japanese != "日本" || e.Furigana != "にほん" {
+		t.Errorf("entries[0] = %s (%s), want %s (%s)", e.Japanese, e.Furigana, "日本", "にほん")
+	}
+	if !e.Common {
+		t.Errorf("e.Common = %t, want %t", e.Common, true)
+	}
+	if !e.HasAudio {
+		t.Errorf("e.HasAudio = %t, want %t", e.HasAudio, true)
+	}
+	if e.EntSeq != "1582710" {
+		t.Errorf("e.EntSeq = %q, want %q", e.EntSeq, "1582710")
+	}
+	if !reflect.DeepEqual(e.Pos, []string{"n"}) {
+		t.Errorf("e.Pos = %q, want %q", e.Pos, []string{"n"})
+	}
+	if !reflect.DeepEqual(e.KanjiTags, []string{"P"}) {
+		t.Errorf("e.KanjiTags = %q, want %q", e.KanjiTags, []string{"P"})
+	}
+	if !reflect.DeepEqual(e.KanaTags, []string{"P"}) {
+		t.Errorf("e.KanaTags = %q, want %q", e.KanaTags, []string{"P"})
+	}
+	if len(e.Glosses) != 2 {
+		t.Fatalf("len(e.Glosses) = %d, want %d", len(e.Glosses), 2)
+	}
+	if e.Glosses[0].English != "Japan" {
+		t.Errorf("e.Glosses[0].English = %q, want %q", e.Glosses[0].English, "Japan")
+	}
+	if e.Glosses[1].English != "Japanese" {
+		t.Errorf("e.Glosses[1].English = %q, want %q", e.Glosses[1].English, "Japanese")
+	}
+	if !reflect.DeepEqual(e.Glosses[1].Related, []string{"和"}) {
+		t.Errorf("e.Glosses[1].Related = %q, want %q", e.Glosses[1].Related, []string{"和"})
+	}
+}
+
+func TestParseLineNumberedSenses(t *testing.T) {
+	line := "上手 [じょうず] /(adj-na,n) (1) skill/skillful/(2) {comp} flattery/EntL1580180/"
+	entries, err := ParseLine(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	glosses := entries[0].Glosses
+	if len(glosses) != 2 {
+		t.Fatalf("len(glosses) = %d, want %d", len(glosses), 2)
+	}
+	if glosses[0].English != "skill/skillful" {
+		t.Errorf("glosses[0].English = %q, want %q", glosses[0].English, "skill/skillful")
+	}
+	if glosses[1].Field == nil || *glosses[1].Field != "comp" {
+		t.Errorf("glosses[1].Field = %v, want %q", glosses[1].Field, "comp")
+	}
+	if !reflect.DeepEqual(entries[0].Pos, []string{"adj-na", "n"}) {
+		t.Errorf("entries[0].Pos = %q, want %q", entries[0].Pos, []string{"adj-na", "n"})
+	}
+}
+
+func TestNewText(t *testing.T) {
+	input := strings.Join([]string{
+		"　？？？ /EDICT2 Japanese-English Electronic Dictionary Files/EntL0000000/",
+		"# comment",
+		"",
+		"垜;安土;堋 [あずち] /(n) mound on which targets are placed (in archery)/firing mound/EntL2542010/",
+		"むかつく /(v5k,vi) (1) to feel sick/(2) to be angry/EntL1012650X/",
+	}, "\n")
+
+	edict := NewText(strings.NewReader(input))
+	var got []string
+	for edict.Scan() {
+		if err := edict.NextEntry(); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, edict.Entry().Japanese)
+	}
+	want := []string{"垜", "安土", "堋", "むかつく"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
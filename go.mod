@@ -5,6 +5,7 @@ go 1.22
 require (
 	github.com/gojp/kana v0.1.0
 	github.com/golang/gddo v0.0.0-20190419222130-af0f2af80721
+	golang.org/x/text v0.22.0
 )
 
 require github.com/google/go-cmp v0.4.1 // indirect
@@ -0,0 +1,306 @@
+// Package jmdict reads the JMdict XML dictionary as a stream of
+// edict2.Entry values.
+package jmdict
+
+import (
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/gojp/nihongo/edict2"
+)
+
+var reEntity = regexp.MustCompile(`<!ENTITY\s+(\S+)\s+"[^"]*"\s*>`)
+
+// priorities that EDICT2 marks with (P)
+var commonPriorities = map[string]bool{
+	"news1": true,
+	"ichi1": true,
+	"spec1": true,
+	"spec2": true,
+	"gai1":  true,
+}
+
+// JMdict reads entries from a JMdict XML file one <entry> element at a time,
+// so the whole document is never held in memory.
+type JMdict struct {
+	decoder *xml.Decoder
+	entry   *edict2.Entry
+
+	// entries expanded from the current <entry> element that have not been
+	// returned by NextEntry yet
+	pending []edict2.Entry
+	err     error
+}
+
+type kanjiElement struct {
+	Keb      string   `xml:"keb"`
+	Info     []string `xml:"ke_inf"`
+	Priority []string `xml:"ke_pri"`
+}
+
+type readingElement struct {
+	Reb          string    `xml:"reb"`
+	NoKanji      *struct{} `xml:"re_nokanji"`
+	Restrictions []string  `xml:"re_restr"`
+	Info         []string  `xml:"re_inf"`
+	Priority     []string  `xml:"re_pri"`
+}
+
+type loanSource struct {
+	Lang     string `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	Type     string `xml:"ls_type,attr"`
+	Wasei    string `xml:"ls_wasei,attr"`
+	Original string `xml:",chardata"`
+}
+
+type gloss struct {
+	Lang string `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	Text string `xml:",chardata"`
+}
+
+type sense struct {
+	KanjiRestrictions   []string     `xml:"stagk"`
+	ReadingRestrictions []string     `xml:"stagr"`
+	Pos                 []string     `xml:"pos"`
+	CrossReferences     []string     `xml:"xref"`
+	Antonyms            []string     `xml:"ant"`
+	Fields              []string     `xml:"field"`
+	Misc                []string     `xml:"misc"`
+	Dialects            []string     `xml:"dial"`
+	Sources             []loanSource `xml:"lsource"`
+	Glosses             []gloss      `xml:"gloss"`
+}
+
+type entry struct {
+	EntSeq   string           `xml:"ent_seq"`
+	Kanji    []kanjiElement   `xml:"k_ele"`
+	Readings []readingElement `xml:"r_ele"`
+	Senses   []sense          `xml:"sense"`
+}
+
+// New returns a JMdict reading XML from r. Entities declared in the
+// document type definition, such as &v5k;, are expanded to their names
+// rather than their descriptions, so they match the EDICT2 tag codes.
+func New(r io.Reader) *JMdict {
+	d := xml.NewDecoder(r)
+	d.Entity = map[string]string{}
+	return &JMdict{decoder: d}
+}
+
+// Scan decodes <entry> elements until one has a kanji and reading pair that
+// its senses apply to, so that NextEntry has entries to return. It returns
+// false at the end of the document or on invalid XML.
+func (j *JMdict) Scan() bool {
+	if len(j.pending) > 0 {
+		return true
+	}
+	if j.err != nil {
+		return false
+	}
+
+	for {
+		tok, err := j.decoder.Token()
+		if err != nil {
+			if err != io.EOF {
+				j.err = err
+			}
+			return false
+		}
+
+		switch t := tok.(type) {
+		case xml.Directive:
+			for _, m := range reEntity.FindAllSubmatch(t, -1) {
+				name := string(m[1])
+				j.decoder.Entity[name] = name
+			}
+		case xml.StartElement:
+			if t.Name.Local != "entry" {
+				continue
+			}
+			var e entry
+			if err := j.decoder.DecodeElement(&e, &t); err != nil {
+				j.err = err
+				return false
+			}
+			j.pending = e.expand()
+			if len(j.pending) > 0 {
+				return true
+			}
+		}
+	}
+}
+
+// NextEntry makes the next entry available through Entry.
+func (j *JMdict) NextEntry() error {
+	if len(j.pending) == 0 {
+		return io.EOF
+	}
+	j.entry = &j.pending[0]
+	j.pending = j.pending[1:]
+	return nil
+}
+
+// Entry returns the entry read by the last call to NextEntry.
+func (j *JMdict) Entry() *edict2.Entry {
+	return j.entry
+}
+
+// Err returns the XML error that stopped Scan, or nil if it reached the end
+// of the document.
+func (j *JMdict) Err() error {
+	return j.err
+}
+
+func isCommon(priorities []string) bool {
+	for _, p := range priorities {
+		if commonPriorities[p] {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+// tags returns info, with "P" appended when the element is common, the way
+// EDICT2 tags its kanji and kana alternatives.
+func tags(info []string, common bool) []string {
+	if common {
+		return append(append([]string{}, info...), "P")
+	}
+	return info
+}
+
+// appliesTo reports whether the sense may be used with the given kanji and
+// reading.
+func (s sense) appliesTo(keb, reb string) bool {
+	if keb != "" && len(s.KanjiRestrictions) > 0 && !contains(s.KanjiRestrictions, keb) {
+		return false
+	}
+	if len(s.ReadingRestrictions) > 0 && !contains(s.ReadingRestrictions, reb) {
+		return false
+	}
+	return true
+}
+
+func (s sense) gloss() (edict2.Gloss, bool) {
+	var english []string
+	for _, g := range s.Glosses {
+		if g.Lang == "" || g.Lang == "eng" {
+			english = append(english, g.Text)
+		}
+	}
+	if len(english) == 0 {
+		return edict2.Gloss{}, false
+	}
+
+	g := edict2.Gloss{
+		English:  strings.Join(english, "/"),
+		Related:  s.CrossReferences,
+		Tags:     s.Misc,
+		Pos:      s.Pos,
+		Dialects: s.Dialects,
+		Antonyms: s.Antonyms,
+	}
+	if len(s.Fields) > 0 {
+		g.Field = &s.Fields[0]
+	}
+	for _, ls := range s.Sources {
+		lang := ls.Lang
+		if lang == "" {
+			lang = "eng"
+		}
+		g.Sources = append(g.Sources, edict2.LoanSource{
+			Lang:     lang,
+			Original: ls.Original,
+			Partial:  ls.Type == "part",
+			Wasei:    ls.Wasei == "y",
+		})
+	}
+	return g, true
+}
+
+// expand turns a JMdict entry into one edict2.Entry per valid combination of
+// kanji and reading, matching the output of the EDICT2 parser.
+func (e entry) expand() []edict2.Entry {
+	// parts of speech carry over to following senses until a sense lists
+	// its own
+	var pos []string
+	for i := range e.Senses {
+		if len(e.Senses[i].Pos) == 0 {
+			e.Senses[i].Pos = pos
+		}
+		pos = e.Senses[i].Pos
+	}
+
+	newEntry := func(keb string, kanjiTags []string, kanjiPri []string, r readingElement) (edict2.Entry, bool) {
+		var glosses []edict2.Gloss
+		var first *sense
+		for i := range e.Senses {
+			s := e.Senses[i]
+			if !s.appliesTo(keb, r.Reb) {
+				continue
+			}
+			g, ok := s.gloss()
+			if !ok {
+				continue
+			}
+			if first == nil {
+				first = &e.Senses[i]
+			}
+			glosses = append(glosses, g)
+		}
+		if first == nil {
+			return edict2.Entry{}, false
+		}
+
+		japanese := keb
+		if japanese == "" {
+			japanese = r.Reb
+		}
+		priority := append(append([]string{}, kanjiPri...), r.Priority...)
+		common := isCommon(priority)
+		return edict2.Entry{
+			Common:    common,
+			Dialects:  first.Dialects,
+			EntSeq:    e.EntSeq,
+			Fields:    first.Fields,
+			Furigana:  r.Reb,
+			Glosses:   glosses,
+			Japanese:  japanese,
+			KanaTags:  tags(r.Info, isCommon(r.Priority)),
+			KanjiTags: kanjiTags,
+			Pos:       first.Pos,
+			Priority:  priority,
+			Tags:      first.Misc,
+		}, true
+	}
+
+	var entries []edict2.Entry
+	for _, r := range e.Readings {
+		if len(e.Kanji) == 0 || r.NoKanji != nil {
+			if ent, ok := newEntry("", nil, nil, r); ok {
+				entries = append(entries, ent)
+			}
+			continue
+		}
+		for _, k := range e.Kanji {
+			if len(r.Restrictions) > 0 && !contains(r.Restrictions, k.Keb) {
+				continue
+			}
+			if ent, ok := newEntry(k.Keb, tags(k.Info, isCommon(k.Priority)), k.Priority, r); ok {
+				entries = append(entries, ent)
+			}
+		}
+	}
+	return entries
+}
@@ -0,0 +1,151 @@
+package jmdict
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gojp/nihongo/edict2"
+)
+
+const sample = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE JMdict [
+<!ELEMENT JMdict (entry*)>
+<!-- entities -->
+<!ENTITY n "noun (common) (futsuumeishi)">
+<!ENTITY v5k "Godan verb with 'ku' ending">
+<!ENTITY vi "intransitive verb">
+<!ENTITY uk "word usually written using kana alone">
+<!ENTITY ksb "Kansai-ben">
+<!ENTITY comp "computing">
+]>
+<JMdict>
+<entry>
+<ent_seq>1582710</ent_seq>
+<k_ele>
+<keb>日本</keb>
+<ke_pri>news1</ke_pri>
+</k_ele>
+<k_ele>
+<keb>日本国</keb>
+</k_ele>
+<r_ele>
+<reb>にほん</reb>
+<re_restr>日本</re_restr>
+<re_pri>news1</re_pri>
+</r_ele>
+<r_ele>
+<reb>にっぽん</reb>
+</r_ele>
+<sense>
+<pos>&n;</pos>
+<gloss>Japan</gloss>
+<gloss xml:lang="ger">Japan</gloss>
+</sense>
+</entry>
+<entry>
+<ent_seq>1012650</ent_seq>
+<r_ele>
+<reb>むかつく</reb>
+</r_ele>
+<sense>
+<pos>&v5k;</pos>
+<pos>&vi;</pos>
+<misc>&uk;</misc>
+<gloss>to feel sick</gloss>
+<gloss>to feel nauseous</gloss>
+</sense>
+<sense>
+<dial>&ksb;</dial>
+<ant>喜ぶ</ant>
+<gloss>to be angry</gloss>
+</sense>
+</entry>
+<entry>
+<ent_seq>1080260</ent_seq>
+<r_ele>
+<reb>ゲーム</reb>
+</r_ele>
+<sense>
+<pos>&n;</pos>
+<field>&comp;</field>
+<lsource ls_wasei="y">game</lsource>
+<gloss>game</gloss>
+</sense>
+</entry>
+</JMdict>`
+
+func readAll(t *testing.T, s string) []edict2.Entry {
+	j := New(strings.NewReader(s))
+	var entries []edict2.Entry
+	for j.Scan() {
+		if err := j.NextEntry(); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, *j.Entry())
+	}
+	if err := j.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func TestJMdict(t *testing.T) {
+	entries := readAll(t, sample)
+
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Japanese+"|"+e.Furigana)
+	}
+	want := []string{"日本|にほん", "日本|にっぽん", "日本国|にっぽん", "むかつく|むかつく", "ゲーム|ゲーム"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("entries = %q, want %q", got, want)
+	}
+
+	nihon := entries[0]
+	if !nihon.Common {
+		t.Errorf("%s Common = %t, want %t", nihon.Japanese, nihon.Common, true)
+	}
+	if !reflect.DeepEqual(nihon.Priority, []string{"news1", "news1"}) {
+		t.Errorf("%s Priority = %q, want %q", nihon.Japanese, nihon.Priority, []string{"news1", "news1"})
+	}
+	if len(nihon.Glosses) != 1 || nihon.Glosses[0].English != "Japan" {
+		t.Errorf("%s Glosses = %v, want a single English gloss", nihon.Japanese, nihon.Glosses)
+	}
+	if entries[2].Common {
+		t.Errorf("%s Common = %t, want %t", entries[2].Japanese, entries[2].Common, false)
+	}
+
+	mukatsuku := entries[3]
+	if !reflect.DeepEqual(mukatsuku.Pos, []string{"v5k", "vi"}) {
+		t.Errorf("Pos = %q, want %q", mukatsuku.Pos, []string{"v5k", "vi"})
+	}
+	if !reflect.DeepEqual(mukatsuku.Tags, []string{"uk"}) {
+		t.Errorf("Tags = %q, want %q", mukatsuku.Tags, []string{"uk"})
+	}
+	if len(mukatsuku.Glosses) != 2 {
+		t.Fatalf("len(Glosses) = %d, want %d", len(mukatsuku.Glosses), 2)
+	}
+	if mukatsuku.Glosses[0].English != "to feel sick/to feel nauseous" {
+		t.Errorf("Glosses[0].English = %q, want %q", mukatsuku.Glosses[0].English, "to feel sick/to feel nauseous")
+	}
+	second := mukatsuku.Glosses[1]
+	if !reflect.DeepEqual(second.Pos, []string{"v5k", "vi"}) {
+		t.Errorf("Glosses[1].Pos = %q, want %q", second.Pos, []string{"v5k", "vi"})
+	}
+	if !reflect.DeepEqual(second.Dialects, []string{"ksb"}) {
+		t.Errorf("Glosses[1].Dialects = %q, want %q", second.Dialects, []string{"ksb"})
+	}
+	if !reflect.DeepEqual(second.Antonyms, []string{"喜ぶ"}) {
+		t.Errorf("Glosses[1].Antonyms = %q, want %q", second.Antonyms, []string{"喜ぶ"})
+	}
+
+	game := entries[4].Glosses[0]
+	if game.Field == nil || *game.Field != "comp" {
+		t.Errorf("Field = %v, want %q", game.Field, "comp")
+	}
+	wantSources := []edict2.LoanSource{{Lang: "eng", Original: "game", Wasei: true}}
+	if !reflect.DeepEqual(game.Sources, wantSources) {
+		t.Errorf("Sources = %v, want %v", game.Sources, wantSources)
+	}
+}
@@ -0,0 +1,149 @@
+// Package kanjidic2 reads the KANJIDIC2 XML kanji dictionary.
+package kanjidic2
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+)
+
+// Kanji holds the readings, meanings and classification of a single kanji.
+type Kanji struct {
+	Literal     string   `json:"literal"`
+	OnReadings  []string `json:"on"`
+	KunReadings []string `json:"kun"`
+	Nanori      []string `json:"nanori,omitempty"`
+	Meanings    []string `json:"meanings"`
+	StrokeCount int      `json:"stroke_count"`
+	Grade       int      `json:"grade,omitempty"`
+	JLPT        int      `json:"jlpt,omitempty"`
+	Frequency   int      `json:"frequency,omitempty"`
+	Radical     int      `json:"radical"`
+}
+
+type radicalValue struct {
+	Type  string `xml:"rad_type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type reading struct {
+	Type  string `xml:"r_type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type meaning struct {
+	Lang  string `xml:"m_lang,attr"`
+	Value string `xml:",chardata"`
+}
+
+type character struct {
+	Literal     string         `xml:"literal"`
+	Radicals    []radicalValue `xml:"radical>rad_value"`
+	Grade       string         `xml:"misc>grade"`
+	StrokeCount []string       `xml:"misc>stroke_count"`
+	Frequency   string         `xml:"misc>freq"`
+	JLPT        string         `xml:"misc>jlpt"`
+	Readings    []reading      `xml:"reading_meaning>rmgroup>reading"`
+	Meanings    []meaning      `xml:"reading_meaning>rmgroup>meaning"`
+	Nanori      []string       `xml:"reading_meaning>nanori"`
+}
+
+// KanjiDic reads kanji from a KANJIDIC2 XML file one <character> element at
+// a time.
+type KanjiDic struct {
+	decoder *xml.Decoder
+	kanji   *Kanji
+	err     error
+}
+
+// New returns a KanjiDic reading XML from r.
+func New(r io.Reader) *KanjiDic {
+	return &KanjiDic{decoder: xml.NewDecoder(r)}
+}
+
+// Scan decodes the next <character> element, skipping the <header>, and
+// makes its kanji available through Kanji. It returns false at the end of
+// the file or on invalid XML.
+func (k *KanjiDic) Scan() bool {
+	if k.err != nil {
+		return false
+	}
+
+	for {
+		tok, err := k.decoder.Token()
+		if err != nil {
+			if err != io.EOF {
+				k.err = err
+			}
+			return false
+		}
+
+		t, ok := tok.(xml.StartElement)
+		if !ok || t.Name.Local != "character" {
+			continue
+		}
+		var c character
+		if err := k.decoder.DecodeElement(&c, &t); err != nil {
+			k.err = err
+			return false
+		}
+		k.kanji = c.kanji()
+		return true
+	}
+}
+
+// Kanji returns the kanji read by the last call to Scan.
+func (k *KanjiDic) Kanji() *Kanji {
+	return k.kanji
+}
+
+// Err returns the XML error that stopped Scan, or nil if it reached the end
+// of the file.
+func (k *KanjiDic) Err() error {
+	return k.err
+}
+
+func atoi(s string) int {
+	i, _ := strconv.Atoi(s)
+	return i
+}
+
+func (c character) kanji() *Kanji {
+	k := &Kanji{
+		Literal:   c.Literal,
+		Nanori:    c.Nanori,
+		Grade:     atoi(c.Grade),
+		JLPT:      atoi(c.JLPT),
+		Frequency: atoi(c.Frequency),
+	}
+
+	// the first stroke count is the accepted one, any others are common
+	// miscounts
+	if len(c.StrokeCount) > 0 {
+		k.StrokeCount = atoi(c.StrokeCount[0])
+	}
+
+	for _, r := range c.Radicals {
+		if r.Type == "classical" {
+			k.Radical = atoi(r.Value)
+			break
+		}
+	}
+
+	for _, r := range c.Readings {
+		switch r.Type {
+		case "ja_on":
+			k.OnReadings = append(k.OnReadings, r.Value)
+		case "ja_kun":
+			k.KunReadings = append(k.KunReadings, r.Value)
+		}
+	}
+
+	for _, m := range c.Meanings {
+		if m.Lang == "" || m.Lang == "en" {
+			k.Meanings = append(k.Meanings, m.Value)
+		}
+	}
+
+	return k
+}
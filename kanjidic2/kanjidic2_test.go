@@ -0,0 +1,82 @@
+package kanjidic2
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sample = `<?xml version="1.0" encoding="UTF-8"?>
+<kanjidic2>
+<header>
+<file_version>4</file_version>
+</header>
+<character>
+<literal>亜</literal>
+<radical>
+<rad_value rad_type="classical">7</rad_value>
+<rad_value rad_type="nelson_c">1</rad_value>
+</radical>
+<misc>
+<grade>8</grade>
+<stroke_count>7</stroke_count>
+<stroke_count>8</stroke_count>
+<freq>1509</freq>
+<jlpt>1</jlpt>
+</misc>
+<reading_meaning>
+<rmgroup>
+<reading r_type="pinyin">ya4</reading>
+<reading r_type="ja_on">ア</reading>
+<reading r_type="ja_kun">つ.ぐ</reading>
+<meaning>Asia</meaning>
+<meaning>rank next</meaning>
+<meaning m_lang="fr">Asie</meaning>
+</rmgroup>
+<nanori>や</nanori>
+</reading_meaning>
+</character>
+<character>
+<literal>唖</literal>
+<radical>
+<rad_value rad_type="classical">30</rad_value>
+</radical>
+<misc>
+<stroke_count>10</stroke_count>
+</misc>
+</character>
+</kanjidic2>`
+
+func TestKanjiDic(t *testing.T) {
+	k := New(strings.NewReader(sample))
+	var got []Kanji
+	for k.Scan() {
+		got = append(got, *k.Kanji())
+	}
+	if err := k.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Kanji{
+		{
+			Literal:     "亜",
+			OnReadings:  []string{"ア"},
+			KunReadings: []string{"つ.ぐ"},
+			Nanori:      []string{"や"},
+			Meanings:    []string{"Asia", "rank next"},
+			StrokeCount: 7,
+			Grade:       8,
+			JLPT:        1,
+			Frequency:   1509,
+			Radical:     7,
+		},
+		{
+			Literal:     "唖",
+			StrokeCount: 10,
+			Radical:     30,
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,211 @@
+package dictionary
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// toHiragana folds katakana in s to hiragana, so kana can be compared
+// regardless of script.
+func toHiragana(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'ァ' && r <= 'ヶ' {
+			return r - ('ァ' - 'ぁ')
+		}
+		return r
+	}, s)
+}
+
+func isKana(s string) bool {
+	for _, r := range s {
+		if !isKanaRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// splitRuns splits word into alternating runs of kana and of other
+// characters, which take their reading from the furigana.
+func splitRuns(word string) []string {
+	var runs []string
+	start := 0
+	for i, r := range word {
+		if i > 0 {
+			prev, _ := utf8.DecodeLastRuneInString(word[:i])
+			if isKanaRune(prev) != isKanaRune(r) {
+				runs = append(runs, word[start:i])
+				start = i
+			}
+		}
+	}
+	if start < len(word) {
+		runs = append(runs, word[start:])
+	}
+	return runs
+}
+
+// matchRuns assigns a part of reading to every run that is not kana, such
+// that the kana runs line up with the reading.
+func matchRuns(runs []string, reading string) ([]RubySegment, bool) {
+	if len(runs) == 0 {
+		return nil, reading == ""
+	}
+
+	run := runs[0]
+	if isKana(run) {
+		folded := toHiragana(run)
+		if !strings.HasPrefix(reading, folded) {
+			return nil, false
+		}
+		rest, ok := matchRuns(runs[1:], reading[len(folded):])
+		if !ok {
+			return nil, false
+		}
+		return append([]RubySegment{{Text: run}}, rest...), true
+	}
+
+	// try the shortest reading first; the kana that follow usually leave
+	// only one choice
+	for i, r := range reading {
+		end := i + utf8.RuneLen(r)
+		if len(runs) == 1 {
+			end = len(reading)
+		}
+		rest, ok := matchRuns(runs[1:], reading[end:])
+		if ok {
+			return append([]RubySegment{{Text: run, Reading: reading[:end]}}, rest...), true
+		}
+		if len(runs) == 1 {
+			break
+		}
+	}
+	return nil, false
+}
+
+// alignReading splits word into segments, giving each run of kanji its
+// part of reading. If the kana in word do not line up with reading, the
+// whole word is returned as one segment.
+func alignReading(word, reading string) []RubySegment {
+	if isKana(word) {
+		return []RubySegment{{Text: word}}
+	}
+	if segments, ok := matchRuns(splitRuns(word), toHiragana(reading)); ok {
+		return segments
+	}
+	return []RubySegment{{Text: word, Reading: reading}}
+}
+
+// rendaku maps kana to their voiced forms, which they can take where two
+// parts of a word meet.
+var rendaku = map[rune][]rune{
+	'か': {'が'}, 'き': {'ぎ'}, 'く': {'ぐ'}, 'け': {'げ'}, 'こ': {'ご'},
+	'さ': {'ざ'}, 'し': {'じ'}, 'す': {'ず'}, 'せ': {'ぜ'}, 'そ': {'ぞ'},
+	'た': {'だ'}, 'ち': {'ぢ', 'じ'}, 'つ': {'づ', 'ず'}, 'て': {'で'}, 'と': {'ど'},
+	'は': {'ば', 'ぱ'}, 'ひ': {'び', 'ぴ'}, 'ふ': {'ぶ', 'ぷ'}, 'へ': {'べ', 'ぺ'}, 'ほ': {'ぼ', 'ぽ'},
+}
+
+// readingVariants returns reading along with the forms it takes inside
+// words: voiced at the start, as in 人々 (ひと + びと), or at the end, as in
+// 手伝う (て + つだ + う), and with a final く, き, ち or つ shortened to っ,
+// as in 学校 (がっ + こう).
+func readingVariants(reading string) []string {
+	variants := []string{reading}
+	first, size := utf8.DecodeRuneInString(reading)
+	for _, v := range rendaku[first] {
+		variants = append(variants, string(v)+reading[size:])
+	}
+	last, size := utf8.DecodeLastRuneInString(reading)
+	if utf8.RuneCountInString(reading) > 1 {
+		for _, v := range rendaku[last] {
+			variants = append(variants, reading[:len(reading)-size]+string(v))
+		}
+	}
+	for _, v := range variants {
+		last, size := utf8.DecodeLastRuneInString(v)
+		if utf8.RuneCountInString(v) > 1 && strings.ContainsRune("くきちつ", last) {
+			variants = append(variants, v[:len(v)-size]+"っ")
+		}
+	}
+	return variants
+}
+
+// kanjiReadings returns the readings of the kanji c in hiragana. Kun
+// readings are given both with and without their okurigana, so た.べる
+// gives たべる and た.
+func (d Dictionary) kanjiReadings(c rune) []string {
+	k, found := d.kanji[c]
+	if !found {
+		return nil
+	}
+
+	var readings []string
+	for _, list := range [][]string{k.OnReadings, k.KunReadings, k.Nanori} {
+		for _, r := range list {
+			r = toHiragana(strings.Trim(r, "-"))
+			if stem, _, found := strings.Cut(r, "."); found {
+				readings = append(readings, stem)
+				r = strings.Replace(r, ".", "", 1)
+			}
+			readings = append(readings, r)
+		}
+	}
+	return readings
+}
+
+// splitKanji divides the reading of the kanji in run[i:] between them,
+// using the readings in KANJIDIC. It reports false unless every kanji gets
+// one of its known readings, as for 今日 (きょう).
+func (d Dictionary) splitKanji(run []rune, i int, reading string) ([]RubySegment, bool) {
+	if i == len(run) {
+		return nil, reading == ""
+	}
+
+	c := run[i]
+	readings := d.kanjiReadings(c)
+	if c == '々' && i > 0 {
+		// the iteration mark repeats the kanji before it
+		readings = d.kanjiReadings(run[i-1])
+	}
+
+	for _, r := range readings {
+		for _, v := range readingVariants(r) {
+			if v == "" || !strings.HasPrefix(reading, v) {
+				continue
+			}
+			if rest, ok := d.splitKanji(run, i+1, reading[len(v):]); ok {
+				return append([]RubySegment{{Text: string(c), Reading: v}}, rest...), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// align splits word into segments, giving each kanji its part of reading
+// where KANJIDIC allows, and each run of kanji its part of reading
+// otherwise.
+func (d Dictionary) align(word, reading string) []RubySegment {
+	var segments []RubySegment
+	for _, s := range alignReading(word, reading) {
+		run := []rune(s.Text)
+		if s.Reading == "" || len(run) < 2 {
+			segments = append(segments, s)
+			continue
+		}
+		if split, ok := d.splitKanji(run, 0, s.Reading); ok {
+			segments = append(segments, split...)
+			continue
+		}
+		segments = append(segments, s)
+	}
+	return segments
+}
+
+// entryRuby aligns the reading of e with its Japanese, returning nil for
+// words without kanji.
+func (d Dictionary) entryRuby(e Entry) []RubySegment {
+	if isKana(e.Japanese) {
+		return nil
+	}
+	return d.align(e.Japanese, e.Furigana)
+}
@@ -0,0 +1,73 @@
+package dictionary
+
+import (
+	"strings"
+	"testing"
+)
+
+var alignReadingTests = []struct {
+	word, reading string
+	want          string // in bracket format
+}{
+	{"猫", "ねこ", "猫[ねこ]"},
+	{"食べる", "たべる", "食[た]べる"},
+	{"取り扱い", "とりあつかい", "取[と]り扱[あつか]い"},
+	{"お茶", "おちゃ", "お茶[ちゃ]"},
+	{"ねこ", "ねこ", "ねこ"},
+	{"見る", "みた", "見る[みた]"},
+}
+
+func TestAlignReading(t *testing.T) {
+	for _, tt := range alignReadingTests {
+		if got := RubyBrackets(alignReading(tt.word, tt.reading)); got != tt.want {
+			t.Errorf("alignReading(%q, %q) = %q, want %q", tt.word, tt.reading, got, tt.want)
+		}
+	}
+}
+
+const alignKanjidic = `<kanjidic2>
+<character><literal>手</literal><reading_meaning><rmgroup><reading r_type="ja_on">シュ</reading><reading r_type="ja_kun">て</reading></rmgroup></reading_meaning></character>
+<character><literal>伝</literal><reading_meaning><rmgroup><reading r_type="ja_on">デン</reading><reading r_type="ja_kun">つた.う</reading></rmgroup></reading_meaning></character>
+<character><literal>学</literal><reading_meaning><rmgroup><reading r_type="ja_on">ガク</reading><reading r_type="ja_kun">まな.ぶ</reading></rmgroup></reading_meaning></character>
+<character><literal>校</literal><reading_meaning><rmgroup><reading r_type="ja_on">コウ</reading></rmgroup></reading_meaning></character>
+<character><literal>人</literal><reading_meaning><rmgroup><reading r_type="ja_on">ジン</reading><reading r_type="ja_kun">ひと</reading></rmgroup></reading_meaning></character>
+<character><literal>今</literal><reading_meaning><rmgroup><reading r_type="ja_on">コン</reading><reading r_type="ja_kun">いま</reading></rmgroup></reading_meaning></character>
+<character><literal>日</literal><reading_meaning><rmgroup><reading r_type="ja_on">ニチ</reading><reading r_type="ja_kun">ひ</reading></rmgroup></reading_meaning></character>
+</kanjidic2>`
+
+var entryRubyTests = []struct {
+	japanese string
+	want     string // in bracket format
+}{
+	{"手伝う", "手[て]伝[つだ]う"},
+	{"学校", "学[がっ]校[こう]"},
+	{"人々", "人[ひと]々[びと]"},
+	{"今日", "今日[きょう]"},
+	{"ねこ", ""},
+}
+
+func TestEntryRuby(t *testing.T) {
+	d := loadText(t,
+		"手伝う [てつだう] /(v5u,vt) to help/(P)/EntL1297450X/",
+		"学校 [がっこう] /(n) school/(P)/EntL1206800X/",
+		"人々 [ひとびと] /(n) people/(P)/EntL1368810X/",
+		"今日 [きょう] /(n-t) today/(P)/EntL1579110X/",
+		"ねこ /(n) cat/EntL2222220X/",
+	)
+
+	// before KANJIDIC is loaded, runs of kanji are not split
+	e := d.Search("手伝う", 1)[0]
+	if got := RubyBrackets(e.Ruby); got != "手伝[てつだ]う" {
+		t.Errorf("e.Ruby = %q, want %q", got, "手伝[てつだ]う")
+	}
+
+	if err := d.LoadKanji(strings.NewReader(alignKanjidic)); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range entryRubyTests {
+		e := d.Search(tt.japanese, 1)[0]
+		if got := RubyBrackets(e.Ruby); got != tt.want {
+			t.Errorf("%s: e.Ruby = %q, want %q", tt.japanese, got, tt.want)
+		}
+	}
+}
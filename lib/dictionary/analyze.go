@@ -0,0 +1,185 @@
+package dictionary
+
+import (
+	"math"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Token is a word found in a piece of Japanese text.
+type Token struct {
+	// Surface is the word as it appears in the text
+	Surface string `json:"surface"`
+	// Base is the dictionary form of the word, which differs from Surface
+	// for conjugated verbs and adjectives
+	Base    string `json:"base"`
+	Reading string `json:"reading"`
+	// Inflections lists the inflections that lead from Base to Surface,
+	// outermost first
+	Inflections []string  `json:"inflections,omitempty"`
+	EntryIDs    []EntryID `json:"entry_ids"`
+}
+
+// costs used by Analyze to choose between segmentations. Every token costs
+// something, so longer words are preferred over several shorter ones.
+const (
+	tokenCost      = 100
+	unknownCost    = 200
+	commonDiscount = 10
+	runeDiscount   = 5
+	inflectionCost = 5
+
+	// maxInflectedRunes bounds the length of the conjugated words Analyze
+	// tries to deinflect
+	maxInflectedRunes = 12
+)
+
+// a latticeEdge is a candidate token spanning text[start:end]
+type latticeEdge struct {
+	start, end int
+	cost       int
+	token      Token
+}
+
+func isKanaRune(r rune) bool {
+	return unicode.In(r, unicode.Hiragana, unicode.Katakana) || r == 'ー'
+}
+
+// dictionaryEdge turns entries found for surface into a candidate token.
+func (d Dictionary) dictionaryEdge(start int, surface string, entries []Entry) latticeEdge {
+	t := Token{
+		Surface:     surface,
+		Base:        entries[0].Japanese,
+		Reading:     entries[0].Furigana,
+		Inflections: entries[0].Inflections,
+	}
+	common := false
+	for _, e := range entries {
+		t.EntryIDs = append(t.EntryIDs, e.ID)
+		common = common || e.Common
+		// prefer an entry that matches the text exactly
+		if len(e.Inflections) == 0 && (e.Japanese == surface || e.Furigana == surface) && t.Base != surface {
+			t.Base, t.Reading = e.Japanese, e.Furigana
+		}
+	}
+
+	cost := tokenCost - runeDiscount*(utf8.RuneCountInString(surface)-1)
+	if common {
+		cost -= commonDiscount
+	}
+	if len(t.Inflections) > 0 {
+		cost += inflectionCost * len(t.Inflections)
+	}
+	return latticeEdge{start: start, end: start + len(surface), cost: cost, token: t}
+}
+
+// edgesFrom returns the candidate tokens that start at text[start:], where
+// normalized is text normalized. deinflected holds the entries already
+// found for each conjugated word tried, which text often repeats.
+func (d Dictionary) edgesFrom(text string, normalized normalizedText, start int, deinflected map[string][]Entry) []latticeEdge {
+	var edges []latticeEdge
+	rest := text[start:]
+
+	// words in the dictionary as they are
+	found := map[int][]Entry{}
+	for _, tree := range []*RadixTree{d.japanese, d.furigana} {
+		normalized.walkPrefixes(tree, start, func(end int, ids []EntryID) {
+			for _, id := range ids {
+				found[end] = append(found[end], d.entries[id])
+			}
+		})
+	}
+	for end, entries := range found {
+		edges = append(edges, d.dictionaryEdge(start, text[start:end], entries))
+	}
+
+	// conjugated words, which always end in kana
+	runes := 0
+	for i, r := range rest {
+		runes++
+		if runes > maxInflectedRunes {
+			break
+		}
+		end := i + utf8.RuneLen(r)
+		if runes < 2 || !isKanaRune(r) {
+			continue
+		}
+		entries, ok := deinflected[rest[:end]]
+		if !ok {
+			entries = d.Deinflect(rest[:end])
+			deinflected[rest[:end]] = entries
+		}
+		if len(entries) > 0 {
+			edges = append(edges, d.dictionaryEdge(start, rest[:end], entries))
+		}
+	}
+
+	// fall back to a single unknown character
+	_, size := utf8.DecodeRuneInString(rest)
+	edges = append(edges, latticeEdge{
+		start: start,
+		end:   start + size,
+		cost:  unknownCost,
+		token: Token{Surface: rest[:size]},
+	})
+
+	return edges
+}
+
+// MaxAnalyzeRunes bounds the length of the text Analyze splits, since every
+// position of the text is looked up under each word that could start there.
+const MaxAnalyzeRunes = 2000
+
+// Analyze splits Japanese text into words, choosing the segmentation with
+// the lowest total cost. Longer words, common words and words needing fewer
+// inflections are preferred. Characters that are not part of any known word
+// are returned as tokens without entries, with runs of such characters
+// merged together; whitespace is dropped. Text longer than MaxAnalyzeRunes
+// is not split at all.
+func (d Dictionary) Analyze(text string) []Token {
+	if utf8.RuneCountInString(text) > MaxAnalyzeRunes {
+		return nil
+	}
+	best := make([]int, len(text)+1)
+	back := make([]*latticeEdge, len(text)+1)
+	for i := range best {
+		best[i] = math.MaxInt32
+	}
+	best[0] = 0
+	normalized := normalizeText(text)
+	deinflected := map[string][]Entry{}
+
+	for i := 0; i < len(text); {
+		_, size := utf8.DecodeRuneInString(text[i:])
+		if best[i] != math.MaxInt32 {
+			for _, e := range d.edgesFrom(text, normalized, i, deinflected) {
+				e := e
+				if c := best[i] + e.cost; c < best[e.end] || (c == best[e.end] && e.start < back[e.end].start) {
+					best[e.end] = c
+					back[e.end] = &e
+				}
+			}
+		}
+		i += size
+	}
+
+	var reversed []Token
+	for i := len(text); i > 0; i = back[i].start {
+		reversed = append(reversed, back[i].token)
+	}
+
+	var tokens []Token
+	for i := len(reversed) - 1; i >= 0; i-- {
+		t := reversed[i]
+		r, _ := utf8.DecodeRuneInString(t.Surface)
+		if t.EntryIDs == nil && unicode.IsSpace(r) {
+			continue
+		}
+		if last := len(tokens) - 1; t.EntryIDs == nil && last >= 0 && tokens[last].EntryIDs == nil {
+			tokens[last].Surface += t.Surface
+			continue
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens
+}
@@ -0,0 +1,90 @@
+package dictionary
+
+import (
+	"strings"
+	"testing"
+)
+
+var analyzeTests = []struct {
+	text string
+	want string // surface/base pairs
+}{
+	{"私は猫が好きです。", "私/私 は/は 猫/猫 が/が 好き/好き です/です 。/"},
+	{"日本に行きたい", "日本/日本 に/に 行きたい/行く"},
+	{"昨日寿司を食べられなかった", "昨日/昨日 寿司/寿司 を/を 食べられなかった/食べる"},
+	{"ＸＹＺ 猫", "ＸＹＺ/ 猫/猫"},
+}
+
+func TestAnalyze(t *testing.T) {
+	d := loadText(t,
+		"私 [わたし] /(pn) I/me/(P)/EntL1311110X/",
+		"は /(prt) topic marker particle/(P)/EntL2028920X/",
+		"が /(prt) subject marker/(P)/EntL2028930X/",
+		"に /(prt) at/in/(P)/EntL2028990X/",
+		"を /(prt) object marker/(P)/EntL2029010X/",
+		"です /(aux-v) be/(P)/EntL1628500X/",
+		"猫 [ねこ] /(n) cat/(P)/EntL1467640X/",
+		"好き [すき] /(adj-na,n) liking/(P)/EntL1584070X/",
+		"日本 [にほん] /(n) Japan/(P)/EntL1582710X/",
+		"日 [ひ] /(n) day/(P)/EntL1604990X/",
+		"本 [ほん] /(n) book/(P)/EntL1522150X/",
+		"行く [いく] /(v5k-s,vi) to go/(P)/EntL1578850X/",
+		"昨日 [きのう] /(n-adv,n-t) yesterday/(P)/EntL1313230X/",
+		"寿司 [すし] /(n) sushi/(P)/EntL1318780X/",
+		"食べる [たべる] /(v1,vt) to eat/(P)/EntL1358280X/",
+	)
+
+	for _, tt := range analyzeTests {
+		var got []string
+		for _, tok := range d.Analyze(tt.text) {
+			got = append(got, tok.Surface+"/"+tok.Base)
+		}
+		if g := strings.Join(got, " "); g != tt.want {
+			t.Errorf("d.Analyze(%q) = %q, want %q", tt.text, g, tt.want)
+		}
+	}
+
+	tokens := d.Analyze("食べられなかった")
+	if len(tokens) != 1 {
+		t.Fatalf("len(tokens) = %d, want %d", len(tokens), 1)
+	}
+	if got := strings.Join(tokens[0].Inflections, " + "); got != "past + negative + potential" {
+		t.Errorf("tokens[0].Inflections = %q, want %q", got, "past + negative + potential")
+	}
+	if tokens[0].Reading != "たべる" {
+		t.Errorf("tokens[0].Reading = %q, want %q", tokens[0].Reading, "たべる")
+	}
+}
+
+func TestAnalyzeTooLong(t *testing.T) {
+	d := loadText(t, "猫 [ねこ] /(n) cat/(P)/EntL1467640X/")
+
+	if tokens := d.Analyze(strings.Repeat("猫", MaxAnalyzeRunes)); len(tokens) == 0 {
+		t.Errorf("d.Analyze of %d runes = no tokens, want some", MaxAnalyzeRunes)
+	}
+	if tokens := d.Analyze(strings.Repeat("猫", MaxAnalyzeRunes+1)); tokens != nil {
+		t.Errorf("d.Analyze of %d runes = %d tokens, want none", MaxAnalyzeRunes+1, len(tokens))
+	}
+}
+
+// BenchmarkAnalyzeLong analyzes text as long as Analyze allows, in which
+// the same conjugated words come up again and again.
+func BenchmarkAnalyzeLong(b *testing.B) {
+	d := loadText(b,
+		"私 [わたし] /(pn) I/me/(P)/EntL1311110X/",
+		"は /(prt) topic marker particle/(P)/EntL2028920X/",
+		"を /(prt) object marker/(P)/EntL2029010X/",
+		"昨日 [きのう] /(n-adv,n-t) yesterday/(P)/EntL1313230X/",
+		"寿司 [すし] /(n) sushi/(P)/EntL1318780X/",
+		"食べる [たべる] /(v1,vt) to eat/(P)/EntL1358280X/",
+	)
+	sentence := "私は昨日寿司を食べられなかった。"
+	text := strings.Repeat(sentence, MaxAnalyzeRunes/len([]rune(sentence)))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if len(d.Analyze(text)) == 0 {
+			b.Fatal("d.Analyze found no tokens")
+		}
+	}
+}
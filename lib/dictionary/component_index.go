@@ -0,0 +1,150 @@
+package dictionary
+
+import (
+	"io"
+	"sort"
+
+	"github.com/gojp/nihongo/radkfile"
+)
+
+// ComponentIndex maps radicals to the kanji containing them, and kanji back
+// to their radicals.
+type ComponentIndex struct {
+	kanji    map[rune]map[rune]bool // radical -> kanji
+	radicals map[rune]map[rune]bool // kanji -> radicals
+	strokes  map[rune]int           // radical -> stroke count
+}
+
+func NewComponentIndex() *ComponentIndex {
+	return &ComponentIndex{
+		kanji:    map[rune]map[rune]bool{},
+		radicals: map[rune]map[rune]bool{},
+		strokes:  map[rune]int{},
+	}
+}
+
+// Insert records that kanji contains radical.
+func (c *ComponentIndex) Insert(kanji, radical rune) {
+	if c.kanji[radical] == nil {
+		c.kanji[radical] = map[rune]bool{}
+	}
+	c.kanji[radical][kanji] = true
+
+	if c.radicals[kanji] == nil {
+		c.radicals[kanji] = map[rune]bool{}
+	}
+	c.radicals[kanji][radical] = true
+}
+
+// Find returns the kanji that contain every one of the given radicals, in
+// no particular order.
+func (c *ComponentIndex) Find(radicals []rune) []rune {
+	if len(radicals) == 0 {
+		return nil
+	}
+
+	// start from the radical with the fewest kanji to keep the
+	// intersection small
+	smallest := c.kanji[radicals[0]]
+	for _, r := range radicals[1:] {
+		if len(c.kanji[r]) < len(smallest) {
+			smallest = c.kanji[r]
+		}
+	}
+
+	var found []rune
+outer:
+	for k := range smallest {
+		for _, r := range radicals {
+			if !c.kanji[r][k] {
+				continue outer
+			}
+		}
+		found = append(found, k)
+	}
+	return found
+}
+
+// Possible returns the radicals, other than the selected ones, that appear
+// in at least one of the given kanji. Adding any of them to the selection
+// still leaves at least one kanji to choose from.
+func (c *ComponentIndex) Possible(kanji []rune, selected []rune) []rune {
+	skip := map[rune]bool{}
+	for _, r := range selected {
+		skip[r] = true
+	}
+
+	var possible []rune
+	for _, k := range kanji {
+		for r := range c.radicals[k] {
+			if !skip[r] {
+				skip[r] = true
+				possible = append(possible, r)
+			}
+		}
+	}
+
+	sort.Slice(possible, func(i, j int) bool {
+		si, sj := c.strokes[possible[i]], c.strokes[possible[j]]
+		if si != sj {
+			return si < sj
+		}
+		return possible[i] < possible[j]
+	})
+	return possible
+}
+
+// LoadRadkfile reads a UTF-8 encoded RADKFILE from r into the dictionary's
+// component index.
+func (d Dictionary) LoadRadkfile(r io.Reader) error {
+	radicals, err := radkfile.ReadRadkfile(r)
+	if err != nil {
+		return err
+	}
+	for _, rad := range radicals {
+		d.components.strokes[rad.Radical] = rad.Strokes
+		for _, k := range rad.Kanji {
+			d.components.Insert(k, rad.Radical)
+		}
+	}
+	return nil
+}
+
+// LoadKradfile reads a UTF-8 encoded KRADFILE from r into the dictionary's
+// component index.
+func (d Dictionary) LoadKradfile(r io.Reader) error {
+	components, err := radkfile.ReadKradfile(r)
+	if err != nil {
+		return err
+	}
+	for k, radicals := range components {
+		for _, rad := range radicals {
+			d.components.Insert(k, rad)
+		}
+	}
+	return nil
+}
+
+// SearchRadicals returns all kanji made up of every one of the given
+// radicals, ordered by stroke count, along with the radicals that can still
+// be added to narrow the search down further.
+func (d Dictionary) SearchRadicals(radicals []rune) (kanji []rune, possible []rune) {
+	kanji = d.components.Find(radicals)
+
+	strokes := func(k rune) int {
+		if info, found := d.kanji[k]; found && info.StrokeCount > 0 {
+			return info.StrokeCount
+		}
+		// kanji missing from KANJIDIC2 go last
+		return int(^uint(0) >> 1)
+	}
+	sort.Slice(kanji, func(i, j int) bool {
+		si, sj := strokes(kanji[i]), strokes(kanji[j])
+		if si != sj {
+			return si < sj
+		}
+		return kanji[i] < kanji[j]
+	})
+
+	return kanji, d.components.Possible(kanji, radicals)
+}
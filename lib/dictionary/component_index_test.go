@@ -0,0 +1,73 @@
+package dictionary
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const radkfileSample = `$ 口 3
+右品呂
+$ 一 1
+右
+$ 木 4
+呆
+`
+
+const radicalKanjidic = `<kanjidic2>
+<character><literal>右</literal><misc><stroke_count>5</stroke_count></misc></character>
+<character><literal>品</literal><misc><stroke_count>9</stroke_count></misc></character>
+<character><literal>呂</literal><misc><stroke_count>7</stroke_count></misc></character>
+</kanjidic2>`
+
+var radicalSearches = []struct {
+	radicals string
+	kanji    string
+	possible string
+}{
+	{"口", "右呂品呆", "一木"},
+	{"木", "呆", "口"},
+	{"口一", "右", ""},
+	{"一木", "", ""},
+	{"", "", ""},
+}
+
+func TestSearchRadicals(t *testing.T) {
+	d, err := Load(strings.NewReader(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.LoadRadkfile(strings.NewReader(radkfileSample)); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.LoadKradfile(strings.NewReader("呆 : 口 木\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.LoadKanji(strings.NewReader(radicalKanjidic)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range radicalSearches {
+		kanji, possible := d.SearchRadicals([]rune(s.radicals))
+		if string(kanji) != s.kanji {
+			t.Errorf("d.SearchRadicals(%q) kanji = %q, want %q", s.radicals, string(kanji), s.kanji)
+		}
+		if string(possible) != s.possible {
+			t.Errorf("d.SearchRadicals(%q) possible = %q, want %q", s.radicals, string(possible), s.possible)
+		}
+	}
+}
+
+func TestComponentIndexFind(t *testing.T) {
+	c := NewComponentIndex()
+	c.Insert('休', '亻')
+	c.Insert('休', '木')
+	c.Insert('体', '亻')
+	c.Insert('体', '木')
+	c.Insert('体', '一')
+
+	got := c.Find([]rune("一亻"))
+	if !reflect.DeepEqual(got, []rune("体")) {
+		t.Errorf("c.Find(%q) = %q, want %q", "一亻", string(got), "体")
+	}
+}
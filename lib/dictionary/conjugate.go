@@ -0,0 +1,364 @@
+package dictionary
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// conjugationForms lists the forms of a conjugation table, in the order
+// they are displayed. Not every word class has every form.
+var conjugationForms = []string{
+	"non-past",
+	"negative",
+	"polite",
+	"polite negative",
+	"past",
+	"past negative",
+	"polite past",
+	"polite past negative",
+	"te-form",
+	"potential",
+	"passive",
+	"causative",
+	"causative passive",
+	"volitional",
+	"polite volitional",
+	"imperative",
+	"conditional",
+	"-tara",
+	"-tai",
+	"adverb",
+}
+
+// Conjugation is a single form in the conjugation table of an entry.
+type Conjugation struct {
+	Form     string `json:"form"`
+	Japanese string `json:"japanese"`
+	Furigana string `json:"furigana"`
+}
+
+// A conjugator returns the endings of every form of word, along with the
+// ending of word they replace. It returns a nil map if it cannot conjugate
+// word.
+type conjugator func(word string) (ending string, forms map[string]string)
+
+func lastRune(s string) string {
+	_, size := utf8.DecodeLastRuneInString(s)
+	return s[len(s)-size:]
+}
+
+func firstRune(s string) string {
+	_, size := utf8.DecodeRuneInString(s)
+	return s[:size]
+}
+
+// withPrefix returns a copy of forms with prefix added to every ending.
+func withPrefix(prefix string, forms map[string]string) map[string]string {
+	prefixed := map[string]string{}
+	for f, ending := range forms {
+		prefixed[f] = prefix + ending
+	}
+	return prefixed
+}
+
+func godanForms(row [7]string) map[string]string {
+	u, a, i, e, o, ta, te := row[0], row[1], row[2], row[3], row[4], row[5], row[6]
+	return map[string]string{
+		"non-past":             u,
+		"negative":             a + "ない",
+		"polite":               i + "ます",
+		"polite negative":      i + "ません",
+		"past":                 ta,
+		"past negative":        a + "なかった",
+		"polite past":          i + "ました",
+		"polite past negative": i + "ませんでした",
+		"te-form":              te,
+		"potential":            e + "る",
+		"passive":              a + "れる",
+		"causative":            a + "せる",
+		"causative passive":    a + "せられる",
+		"volitional":           o + "う",
+		"polite volitional":    i + "ましょう",
+		"imperative":           e,
+		"conditional":          e + "ば",
+		"-tara":                ta + "ら",
+		"-tai":                 i + "たい",
+	}
+}
+
+// godan conjugates v5 verbs, applying the given overrides on top of the
+// regular forms for the verb's ending.
+func godan(overrides map[string]string) conjugator {
+	return func(word string) (string, map[string]string) {
+		u := lastRune(word)
+		for _, row := range godanRows {
+			if row[0] != u {
+				continue
+			}
+			forms := godanForms(row)
+			for f, ending := range overrides {
+				forms[f] = ending
+			}
+			return u, forms
+		}
+		return "", nil
+	}
+}
+
+// aru conjugates ある (v5r-i), whose negative is a bare ない.
+func aru(word string) (string, map[string]string) {
+	if utf8.RuneCountInString(word) < 2 || lastRune(word) != "る" {
+		return "", nil
+	}
+	ending := lastRune(strings.TrimSuffix(word, "る")) + "る"
+	forms := withPrefix(firstRune(ending), godanForms(godanRows[len(godanRows)-1]))
+	forms["negative"] = "ない"
+	forms["past negative"] = "なかった"
+	return ending, forms
+}
+
+func ichidanForms(imperative string) map[string]string {
+	return map[string]string{
+		"non-past":             "る",
+		"negative":             "ない",
+		"polite":               "ます",
+		"polite negative":      "ません",
+		"past":                 "た",
+		"past negative":        "なかった",
+		"polite past":          "ました",
+		"polite past negative": "ませんでした",
+		"te-form":              "て",
+		"potential":            "られる",
+		"passive":              "られる",
+		"causative":            "させる",
+		"causative passive":    "させられる",
+		"volitional":           "よう",
+		"polite volitional":    "ましょう",
+		"imperative":           imperative,
+		"conditional":          "れば",
+		"-tara":                "たら",
+		"-tai":                 "たい",
+	}
+}
+
+// ichidan conjugates v1 verbs, and v1-s verbs such as くれる whose
+// imperative drops the ろ.
+func ichidan(imperative string) conjugator {
+	return func(word string) (string, map[string]string) {
+		if !strings.HasSuffix(word, "る") {
+			return "", nil
+		}
+		return "る", ichidanForms(imperative)
+	}
+}
+
+var kuruForms = map[string]string{
+	"non-past":             "くる",
+	"negative":             "こない",
+	"polite":               "きます",
+	"polite negative":      "きません",
+	"past":                 "きた",
+	"past negative":        "こなかった",
+	"polite past":          "きました",
+	"polite past negative": "きませんでした",
+	"te-form":              "きて",
+	"potential":            "こられる",
+	"passive":              "こられる",
+	"causative":            "こさせる",
+	"causative passive":    "こさせられる",
+	"volitional":           "こよう",
+	"polite volitional":    "きましょう",
+	"imperative":           "こい",
+	"conditional":          "くれば",
+	"-tara":                "きたら",
+	"-tai":                 "きたい",
+}
+
+var suruForms = map[string]string{
+	"non-past":             "する",
+	"negative":             "しない",
+	"polite":               "します",
+	"polite negative":      "しません",
+	"past":                 "した",
+	"past negative":        "しなかった",
+	"polite past":          "しました",
+	"polite past negative": "しませんでした",
+	"te-form":              "して",
+	"potential":            "できる",
+	"passive":              "される",
+	"causative":            "させる",
+	"causative passive":    "させられる",
+	"volitional":           "しよう",
+	"polite volitional":    "しましょう",
+	"imperative":           "しろ",
+	"conditional":          "すれば",
+	"-tara":                "したら",
+	"-tai":                 "したい",
+}
+
+// kanjiForms rewrites the kana forms of an irregular verb for when its
+// first kana is written with the kanji k, as in 来る.
+func kanjiForms(k string, forms map[string]string) map[string]string {
+	written := map[string]string{}
+	for f, ending := range forms {
+		written[f] = k + strings.TrimPrefix(ending, firstRune(ending))
+	}
+	return written
+}
+
+// irregular conjugates 来る and する, whether written in kana or with the
+// given kanji.
+func irregular(kana, kanji string, forms map[string]string) conjugator {
+	return func(word string) (string, map[string]string) {
+		for _, k := range strings.Split(kanji, "") {
+			if strings.HasSuffix(word, k+"る") {
+				written := kanjiForms(k, forms)
+				if kana == "する" {
+					// 為る has no kanji potential form of its own
+					written["potential"] = "出来る"
+				}
+				return k + "る", written
+			}
+		}
+		if strings.HasSuffix(word, kana) {
+			return kana, forms
+		}
+		return "", nil
+	}
+}
+
+// suruNoun conjugates nouns that take する, such as 勉強.
+func suruNoun(word string) (string, map[string]string) {
+	return "", suruForms
+}
+
+// suruSpecial conjugates vs-s verbs such as 愛する, which mix する with
+// godan forms.
+func suruSpecial(word string) (string, map[string]string) {
+	if !strings.HasSuffix(word, "する") {
+		return "", nil
+	}
+	forms := map[string]string{}
+	for f, ending := range suruForms {
+		forms[f] = ending
+	}
+	forms["negative"] = "さない"
+	forms["past negative"] = "さなかった"
+	forms["potential"] = "せる"
+	forms["volitional"] = "そう"
+	forms["imperative"] = "せよ"
+	return "する", forms
+}
+
+var adjIForms = map[string]string{
+	"non-past":             "い",
+	"negative":             "くない",
+	"polite":               "いです",
+	"polite negative":      "くないです",
+	"past":                 "かった",
+	"past negative":        "くなかった",
+	"polite past":          "かったです",
+	"polite past negative": "くなかったです",
+	"te-form":              "くて",
+	"conditional":          "ければ",
+	"-tara":                "かったら",
+	"adverb":               "く",
+}
+
+func adjI(word string) (string, map[string]string) {
+	if !strings.HasSuffix(word, "い") {
+		return "", nil
+	}
+	return "い", adjIForms
+}
+
+// adjIx conjugates いい, whose forms other than the non-past are those of
+// よい. Kanji spellings such as 良い conjugate regularly.
+func adjIx(word string) (string, map[string]string) {
+	if !strings.HasSuffix(word, "いい") {
+		return adjI(word)
+	}
+	forms := withPrefix("よ", adjIForms)
+	forms["non-past"] = "いい"
+	forms["polite"] = "いいです"
+	return "いい", forms
+}
+
+var adjNaForms = map[string]string{
+	"non-past":             "だ",
+	"negative":             "じゃない",
+	"polite":               "です",
+	"polite negative":      "じゃありません",
+	"past":                 "だった",
+	"past negative":        "じゃなかった",
+	"polite past":          "でした",
+	"polite past negative": "じゃありませんでした",
+	"te-form":              "で",
+	"conditional":          "なら",
+	"-tara":                "だったら",
+	"adverb":               "に",
+}
+
+func adjNa(word string) (string, map[string]string) {
+	return "", adjNaForms
+}
+
+// conjugators maps parts of speech to the conjugator for their word class.
+var conjugators = map[string]conjugator{
+	"v1":     ichidan("ろ"),
+	"v1-s":   ichidan(""),
+	"v5b":    godan(nil),
+	"v5g":    godan(nil),
+	"v5k":    godan(nil),
+	"v5k-s":  godan(map[string]string{"past": "った", "te-form": "って", "-tara": "ったら"}),
+	"v5m":    godan(nil),
+	"v5n":    godan(nil),
+	"v5r":    godan(nil),
+	"v5r-i":  aru,
+	"v5s":    godan(nil),
+	"v5t":    godan(nil),
+	"v5u":    godan(nil),
+	"v5u-s":  godan(map[string]string{"past": "うた", "te-form": "うて", "-tara": "うたら"}),
+	"v5aru":  godan(map[string]string{"polite": "います", "polite negative": "いません", "polite past": "いました", "polite past negative": "いませんでした", "polite volitional": "いましょう", "imperative": "い"}),
+	"vk":     irregular("くる", "来來", kuruForms),
+	"vs-i":   irregular("する", "為", suruForms),
+	"vs-s":   suruSpecial,
+	"vs":     suruNoun,
+	"adj-i":  adjI,
+	"adj-ix": adjIx,
+	"adj-na": adjNa,
+}
+
+// Conjugate returns the conjugation table of a verb or adjective entry,
+// with every form written both like the entry's Japanese and like its
+// Furigana. It returns nil for entries that do not conjugate.
+func Conjugate(e Entry) []Conjugation {
+	for _, p := range e.Pos {
+		conj, ok := conjugators[p]
+		if !ok {
+			continue
+		}
+
+		jEnding, jForms := conj(e.Japanese)
+		fEnding, fForms := conj(e.Furigana)
+		if jForms == nil || fForms == nil {
+			continue
+		}
+
+		var table []Conjugation
+		for _, f := range conjugationForms {
+			j, jok := jForms[f]
+			k, kok := fForms[f]
+			if !jok || !kok {
+				continue
+			}
+			table = append(table, Conjugation{
+				Form:     f,
+				Japanese: strings.TrimSuffix(e.Japanese, jEnding) + j,
+				Furigana: strings.TrimSuffix(e.Furigana, fEnding) + k,
+			})
+		}
+		return table
+	}
+	return nil
+}
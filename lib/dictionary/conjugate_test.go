@@ -0,0 +1,66 @@
+package dictionary
+
+import (
+	"testing"
+
+	"github.com/gojp/nihongo/edict2"
+)
+
+var conjugateTests = []struct {
+	japanese string
+	furigana string
+	pos      []string
+	form     string
+	want     string
+	wantKana string
+}{
+	{"食べる", "たべる", []string{"v1", "vt"}, "negative", "食べない", "たべない"},
+	{"食べる", "たべる", []string{"v1", "vt"}, "imperative", "食べろ", "たべろ"},
+	{"呉れる", "くれる", []string{"v1-s", "vt"}, "imperative", "呉れ", "くれ"},
+	{"書く", "かく", []string{"v5k", "vt"}, "past", "書いた", "かいた"},
+	{"書く", "かく", []string{"v5k", "vt"}, "causative passive", "書かせられる", "かかせられる"},
+	{"買う", "かう", []string{"v5u", "vt"}, "negative", "買わない", "かわない"},
+	{"読む", "よむ", []string{"v5m", "vt"}, "te-form", "読んで", "よんで"},
+	{"行く", "いく", []string{"v5k-s", "vi"}, "past", "行った", "いった"},
+	{"行く", "いく", []string{"v5k-s", "vi"}, "polite", "行きます", "いきます"},
+	{"問う", "とう", []string{"v5u-s", "vt"}, "te-form", "問うて", "とうて"},
+	{"有る", "ある", []string{"v5r-i", "vi"}, "negative", "ない", "ない"},
+	{"有る", "ある", []string{"v5r-i", "vi"}, "polite", "有ります", "あります"},
+	{"下さる", "くださる", []string{"v5aru", "vt"}, "polite", "下さいます", "くださいます"},
+	{"来る", "くる", []string{"vk", "vi"}, "negative", "来ない", "こない"},
+	{"来る", "くる", []string{"vk", "vi"}, "conditional", "来れば", "くれば"},
+	{"為る", "する", []string{"vs-i"}, "potential", "出来る", "できる"},
+	{"為る", "する", []string{"vs-i"}, "past", "為た", "した"},
+	{"勉強", "べんきょう", []string{"n", "vs"}, "polite past", "勉強しました", "べんきょうしました"},
+	{"愛する", "あいする", []string{"vs-s", "vt"}, "negative", "愛さない", "あいさない"},
+	{"高い", "たかい", []string{"adj-i"}, "past negative", "高くなかった", "たかくなかった"},
+	{"いい", "いい", []string{"adj-ix"}, "past", "よかった", "よかった"},
+	{"いい", "いい", []string{"adj-ix"}, "non-past", "いい", "いい"},
+	{"静か", "しずか", []string{"adj-na", "n"}, "past", "静かだった", "しずかだった"},
+}
+
+func TestConjugate(t *testing.T) {
+	for _, tt := range conjugateTests {
+		e := Entry{Entry: edict2.Entry{Japanese: tt.japanese, Furigana: tt.furigana, Pos: tt.pos}}
+		var found bool
+		for _, c := range Conjugate(e) {
+			if c.Form != tt.form {
+				continue
+			}
+			found = true
+			if c.Japanese != tt.want || c.Furigana != tt.wantKana {
+				t.Errorf("Conjugate(%s) %s = %s (%s), want %s (%s)", tt.japanese, tt.form, c.Japanese, c.Furigana, tt.want, tt.wantKana)
+			}
+		}
+		if !found {
+			t.Errorf("Conjugate(%s) has no %s form", tt.japanese, tt.form)
+		}
+	}
+}
+
+func TestConjugateNoun(t *testing.T) {
+	e := Entry{Entry: edict2.Entry{Japanese: "猫", Furigana: "ねこ", Pos: []string{"n"}}}
+	if table := Conjugate(e); table != nil {
+		t.Errorf("Conjugate(%s) = %v, want nil", e.Japanese, table)
+	}
+}
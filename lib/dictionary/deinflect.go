@@ -0,0 +1,309 @@
+package dictionary
+
+import (
+	"slices"
+	"strings"
+)
+
+// wordType is a set of word classes, as bit flags, that a word may belong
+// to while it is being deinflected.
+type wordType uint
+
+const (
+	// typeInitial marks forms that do not conjugate any further, and so can
+	// only appear at the end of the word being deinflected.
+	typeInitial wordType = 1 << iota
+	// typeIchidan is v1, and the forms that conjugate like it, such as the
+	// potential 食べられる
+	typeIchidan
+	// typeGodan is any v5 verb
+	typeGodan
+	// typeAdjI is adj-i, and the forms that conjugate like it, such as the
+	// negative 食べない
+	typeAdjI
+	// typeKuru is 来る
+	typeKuru
+	// typeSuru is する and the する form of vs nouns
+	typeSuru
+	// typeTe is the te-form, which 〜ている builds on
+	typeTe
+
+	typeAny = typeInitial | typeIchidan | typeGodan | typeAdjI | typeKuru | typeSuru | typeTe
+)
+
+// A deinflectRule rewrites the suffix from of a word of type in to the
+// suffix to, giving a word of type out.
+type deinflectRule struct {
+	from   string
+	to     string
+	in     wordType
+	out    wordType
+	reason string
+}
+
+// godanRows lists, for every godan ending, its a-, i-, e- and o-stem
+// endings followed by its past and te-form endings.
+var godanRows = [][7]string{
+	{"う", "わ", "い", "え", "お", "った", "って"},
+	{"く", "か", "き", "け", "こ", "いた", "いて"},
+	{"ぐ", "が", "ぎ", "げ", "ご", "いだ", "いで"},
+	{"す", "さ", "し", "せ", "そ", "した", "して"},
+	{"つ", "た", "ち", "て", "と", "った", "って"},
+	{"ぬ", "な", "に", "ね", "の", "んだ", "んで"},
+	{"ぶ", "ば", "び", "べ", "ぼ", "んだ", "んで"},
+	{"む", "ま", "み", "め", "も", "んだ", "んで"},
+	{"る", "ら", "り", "れ", "ろ", "った", "って"},
+}
+
+// politeRules lists the endings that follow the masu-stem of a verb.
+var politeRules = []struct {
+	suffix string
+	reason string
+}{
+	{"ます", "polite"},
+	{"ました", "polite past"},
+	{"ません", "polite negative"},
+	{"ませんでした", "polite past negative"},
+	{"ましょう", "polite volitional"},
+}
+
+// irregularStems holds the conjugated forms of 来る or する.
+type irregularStems struct {
+	dictionary  string
+	typ         wordType
+	negative    string
+	masu        string
+	past        string
+	te          string
+	potential   string
+	passive     string
+	causative   string
+	volitional  string
+	imperatives []string
+	conditional string
+}
+
+var irregulars = []irregularStems{
+	{"くる", typeKuru, "こない", "き", "きた", "きて", "こられる", "こられる", "こさせる", "こよう", []string{"こい"}, "くれば"},
+	{"来る", typeKuru, "来ない", "来", "来た", "来て", "来られる", "来られる", "来させる", "来よう", []string{"来い"}, "来れば"},
+	{"する", typeSuru, "しない", "し", "した", "して", "できる", "される", "させる", "しよう", []string{"しろ", "せよ"}, "すれば"},
+}
+
+// deinflectRules is the table of suffix rewrite rules used by Deinflect.
+var deinflectRules = buildDeinflectRules()
+
+func buildDeinflectRules() []deinflectRule {
+	var rules []deinflectRule
+	add := func(from, to string, in, out wordType, reason string) {
+		rules = append(rules, deinflectRule{from, to, in, out, reason})
+	}
+	addPolite := func(stem, to string, out wordType) {
+		for _, p := range politeRules {
+			add(stem+p.suffix, to, typeInitial, out, p.reason)
+		}
+	}
+
+	// godan verbs
+	for _, row := range godanRows {
+		u, a, i, e, o, ta, te := row[0], row[1], row[2], row[3], row[4], row[5], row[6]
+		add(a+"ない", u, typeAdjI, typeGodan, "negative")
+		add(ta, u, typeInitial, typeGodan, "past")
+		add(ta+"ら", u, typeInitial, typeGodan, "-tara")
+		add(te, u, typeInitial|typeTe, typeGodan, "te-form")
+		addPolite(i, u, typeGodan)
+		add(i+"たい", u, typeAdjI, typeGodan, "-tai")
+		add(e+"る", u, typeIchidan, typeGodan, "potential")
+		add(a+"れる", u, typeIchidan, typeGodan, "passive")
+		add(a+"せる", u, typeIchidan, typeGodan, "causative")
+		add(o+"う", u, typeInitial, typeGodan, "volitional")
+		add(e, u, typeInitial, typeGodan, "imperative")
+		add(e+"ば", u, typeInitial, typeGodan, "conditional")
+	}
+
+	// godan exceptions: 行く (v5k-s) and 問う (v5u-s)
+	for _, iku := range []string{"行", "い"} {
+		add(iku+"った", iku+"く", typeInitial, typeGodan, "past")
+		add(iku+"ったら", iku+"く", typeInitial, typeGodan, "-tara")
+		add(iku+"って", iku+"く", typeInitial|typeTe, typeGodan, "te-form")
+	}
+	add("うた", "う", typeInitial, typeGodan, "past")
+	add("うて", "う", typeInitial|typeTe, typeGodan, "te-form")
+
+	// ichidan verbs
+	add("ない", "る", typeAdjI, typeIchidan, "negative")
+	add("た", "る", typeInitial, typeIchidan, "past")
+	add("たら", "る", typeInitial, typeIchidan, "-tara")
+	add("て", "る", typeInitial|typeTe, typeIchidan, "te-form")
+	addPolite("", "る", typeIchidan)
+	add("たい", "る", typeAdjI, typeIchidan, "-tai")
+	// the passive of ichidan verbs looks the same as the potential
+	add("られる", "る", typeIchidan, typeIchidan, "potential")
+	add("させる", "る", typeIchidan, typeIchidan, "causative")
+	add("よう", "る", typeInitial, typeIchidan, "volitional")
+	add("ろ", "る", typeInitial, typeIchidan, "imperative")
+	add("よ", "る", typeInitial, typeIchidan, "imperative")
+	add("れば", "る", typeInitial, typeIchidan, "conditional")
+
+	// 来る and する
+	for _, irr := range irregulars {
+		d := irr.dictionary
+		add(irr.negative, d, typeAdjI, irr.typ, "negative")
+		add(irr.past, d, typeInitial, irr.typ, "past")
+		add(irr.past+"ら", d, typeInitial, irr.typ, "-tara")
+		add(irr.te, d, typeInitial|typeTe, irr.typ, "te-form")
+		addPolite(irr.masu, d, irr.typ)
+		add(irr.masu+"たい", d, typeAdjI, irr.typ, "-tai")
+		add(irr.potential, d, typeIchidan, irr.typ, "potential")
+		if irr.passive != irr.potential {
+			add(irr.passive, d, typeIchidan, irr.typ, "passive")
+		}
+		add(irr.causative, d, typeIchidan, irr.typ, "causative")
+		add(irr.volitional, d, typeInitial, irr.typ, "volitional")
+		for _, imp := range irr.imperatives {
+			add(imp, d, typeInitial, irr.typ, "imperative")
+		}
+		add(irr.conditional, d, typeInitial, irr.typ, "conditional")
+	}
+
+	// i-adjectives
+	add("くない", "い", typeAdjI, typeAdjI, "negative")
+	add("かった", "い", typeInitial, typeAdjI, "past")
+	add("かったら", "い", typeInitial, typeAdjI, "-tara")
+	add("くて", "い", typeInitial, typeAdjI, "te-form")
+	add("ければ", "い", typeInitial, typeAdjI, "conditional")
+	add("く", "い", typeInitial, typeAdjI, "adverb")
+	add("さ", "い", typeInitial, typeAdjI, "noun")
+
+	// forms built on the negative and the te-form
+	add("ないで", "ない", typeInitial, typeAdjI, "te-form")
+	add("ている", "て", typeIchidan, typeTe, "progressive")
+	add("てる", "て", typeIchidan, typeTe, "progressive")
+	add("でいる", "で", typeIchidan, typeTe, "progressive")
+	add("でる", "で", typeIchidan, typeTe, "progressive")
+
+	return rules
+}
+
+// maxDeinflections bounds the number of candidates Deinflect generates, to
+// keep the request time bounded for long input.
+const maxDeinflections = 500
+
+// Deinflection is a possible dictionary form of an inflected word.
+type Deinflection struct {
+	Word string
+	// Reasons lists the inflections that were undone, outermost first, so
+	// that 食べられなかった gives past, negative, potential.
+	Reasons []string
+
+	typ wordType
+}
+
+// Deinflect returns the possible dictionary forms of word, found by
+// repeatedly undoing inflections. The candidates are not checked against
+// the dictionary, so most of them will not be real words. The word itself
+// is not included.
+func Deinflect(word string) []Deinflection {
+	candidates := []Deinflection{{Word: word, typ: typeAny}}
+	seen := map[string]wordType{word: typeAny}
+
+	for i := 0; i < len(candidates) && len(candidates) < maxDeinflections; i++ {
+		c := candidates[i]
+		for _, rule := range deinflectRules {
+			if c.typ&rule.in == 0 || !strings.HasSuffix(c.Word, rule.from) {
+				continue
+			}
+			w := c.Word[:len(c.Word)-len(rule.from)] + rule.to
+			if w == "" || seen[w]&rule.out == rule.out {
+				continue
+			}
+			seen[w] |= rule.out
+
+			reasons := make([]string, len(c.Reasons), len(c.Reasons)+1)
+			copy(reasons, c.Reasons)
+			candidates = append(candidates, Deinflection{
+				Word:    w,
+				Reasons: append(reasons, rule.reason),
+				typ:     rule.out,
+			})
+		}
+	}
+
+	return candidates[1:]
+}
+
+// String returns the chain of inflections, such as "past + negative".
+func (d Deinflection) String() string {
+	return strings.Join(d.Reasons, " + ")
+}
+
+func hasPos(pos []string, match func(p string) bool) bool {
+	for _, p := range pos {
+		if match(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesPos reports whether an entry with the given parts of speech can be
+// the dictionary form of a word of type t.
+func (t wordType) matchesPos(pos []string) bool {
+	return hasPos(pos, func(p string) bool {
+		switch {
+		case t&typeIchidan != 0 && (p == "v1" || p == "v1-s"):
+			return true
+		case t&typeGodan != 0 && strings.HasPrefix(p, "v5"):
+			return true
+		case t&typeAdjI != 0 && (p == "adj-i" || p == "adj-ix"):
+			return true
+		case t&typeKuru != 0 && p == "vk":
+			return true
+		case t&typeSuru != 0 && (p == "vs-i" || p == "vs-s"):
+			return true
+		}
+		return false
+	})
+}
+
+// Deinflect looks up the possible dictionary forms of word, and returns the
+// entries whose parts of speech allow the inflections that were undone.
+// Each entry's Inflections field records those inflections.
+func (d Dictionary) Deinflect(word string) (results []Entry) {
+	added := map[EntryID]bool{}
+	add := func(ids []EntryID, di Deinflection, match func(pos []string) bool) {
+		for _, id := range ids {
+			e := d.entries[id]
+			if added[id] || !match(e.Pos) {
+				continue
+			}
+			added[id] = true
+			e.Inflections = di.Reasons
+			results = append(results, e)
+		}
+	}
+
+	for _, di := range Deinflect(word) {
+		key := normalizeKey(di.Word)
+		ids := d.japanese.Get(key)
+		if len(ids) == 0 {
+			// only okurigana inside the word may differ, since those at
+			// its end are the ones that were inflected
+			ids = slices.DeleteFunc(d.okuriganaEntries(di.Word), func(id EntryID) bool {
+				return finalOkurigana(d.entries[id].Japanese) != finalOkurigana(di.Word)
+			})
+		}
+		add(ids, di, di.typ.matchesPos)
+		add(d.furigana.Get(key), di, di.typ.matchesPos)
+
+		// nouns taking する are listed without it, as in 勉強 (n,vs)
+		if stem := strings.TrimSuffix(di.Word, "する"); di.typ&typeSuru != 0 && stem != di.Word && stem != "" {
+			isVs := func(pos []string) bool {
+				return hasPos(pos, func(p string) bool { return p == "vs" })
+			}
+			key := normalizeKey(stem)
+			add(d.japanese.Get(key), di, isVs)
+			add(d.furigana.Get(key), di, isVs)
+		}
+	}
+	return results
+}
@@ -0,0 +1,81 @@
+package dictionary
+
+import (
+	"strings"
+	"testing"
+)
+
+var deinflectTests = []struct {
+	word       string
+	want       string
+	inflection string
+}{
+	{"食べられなかった", "食べる", "past + negative + potential"},
+	{"たべます", "食べる", "polite"},
+	{"高くて", "高い", "te-form"},
+	{"高くなかった", "高い", "past + negative"},
+	{"書かせられた", "書く", "past + potential + causative"},
+	{"書いている", "書く", "progressive + te-form"},
+	{"読んだ", "読む", "past"},
+	{"行った", "行く", "past"},
+	{"来なかった", "来る", "past + negative"},
+	{"こない", "来る", "negative"},
+	{"勉強しました", "勉強", "polite past"},
+	{"したい", "為る", "-tai"},
+}
+
+func TestDeinflect(t *testing.T) {
+	d := loadText(t,
+		"食べる [たべる] /(v1,vt) to eat/(P)/EntL1358280X/",
+		"高い [たかい] /(adj-i) high/tall/expensive/(P)/EntL1406060X/",
+		"書く [かく] /(v5k,vt) to write/(P)/EntL1305090X/",
+		"読む [よむ] /(v5m,vt) to read/(P)/EntL1467640X/",
+		"行く;往く [いく;ゆく] /(v5k-s,vi) to go/(P)/EntL1578850X/",
+		"来る [くる] /(vk,vi) to come/(P)/EntL1547720X/",
+		"勉強 [べんきょう] /(n,vs) study/(P)/EntL1512770X/",
+		"為る [する] /(vs-i) to do/(P)/EntL1157170X/",
+		"書 [しょ] /(n) document/EntL1305080/",
+	)
+
+	for _, tt := range deinflectTests {
+		results := d.Deinflect(tt.word)
+		if len(results) == 0 {
+			t.Errorf("d.Deinflect(%q) returned no results, want %q", tt.word, tt.want)
+			continue
+		}
+		got := results[0]
+		if got.Japanese != tt.want {
+			t.Errorf("d.Deinflect(%q)[0] = %q, want %q", tt.word, got.Japanese, tt.want)
+		}
+		if inflection := strings.Join(got.Inflections, " + "); inflection != tt.inflection {
+			t.Errorf("d.Deinflect(%q)[0] inflection = %q, want %q", tt.word, inflection, tt.inflection)
+		}
+	}
+}
+
+func TestDeinflectChecksPos(t *testing.T) {
+	d := loadText(t,
+		"書 [しょ] /(n) document/EntL1305080/",
+		"見る [みる] /(v1,vt) to see/(P)/EntL1259290X/",
+	)
+
+	// 書た would be the past of a nonexistent ichidan verb 書る, and 書 is
+	// a noun in any case
+	if results := d.Deinflect("書いた"); len(results) != 0 {
+		t.Errorf("d.Deinflect(%q) = %v, want no results", "書いた", results)
+	}
+	if results := d.Deinflect("見た"); len(results) != 1 || results[0].Japanese != "見る" {
+		t.Errorf("d.Deinflect(%q) = %v, want %q", "見た", results, "見る")
+	}
+}
+
+func TestSearchDeinflects(t *testing.T) {
+	d := loadText(t, "食べる [たべる] /(v1,vt) to eat/(P)/EntL1358280X/")
+
+	for _, s := range []string{"食べられなかった", "tabemashita"} {
+		results := d.Search(s, 10)
+		if len(results) != 1 || results[0].Japanese != "食べる" {
+			t.Errorf("d.Search(%q) = %v, want %q", s, results, "食べる")
+		}
+	}
+}
@@ -1,13 +1,11 @@
 package dictionary
 
 import (
-	"container/heap"
 	"io"
-	"sort"
 	"strings"
 
-	"github.com/gojp/kana"
 	"github.com/gojp/nihongo/edict2"
+	"github.com/gojp/nihongo/kanjidic2"
 )
 
 type EntryID uint64
@@ -15,13 +13,64 @@ type EntryID uint64
 type Entry struct {
 	edict2.Entry
 	ID EntryID
+
+	// Inflections lists the inflections that were undone to find this
+	// entry, outermost first. It is only set on search results.
+	Inflections []string
+
+	// MatchedGlosses lists the indexes of the glosses that matched an
+	// English search or phrase, best first. It is only set on search
+	// results.
+	MatchedGlosses []int
+
+	// MatchedBy is the source that found the entry, telling matches of its
+	// reading from matches of its English meaning. It is only set on the
+	// results of plain searches.
+	MatchedBy SearchSource
+
+	// Ruby splits Japanese into pieces with their part of Furigana, down
+	// to single kanji where the readings in KANJIDIC allow. It is nil for
+	// words written in kana.
+	Ruby []RubySegment
+
+	// Romaji is Furigana in modified Hepburn romaji, as written by
+	// Romanize.
+	Romaji string
+
+	// AlsoWritten and AlsoRead list the other spellings and readings of
+	// the word from its EDICT2 line, such as 取扱い and 取扱 for 取り扱い.
+	// They are only set on entries returned by Get and by searches.
+	AlsoWritten []string
+	AlsoRead    []string
 }
 
 type Dictionary struct {
 	entries  map[EntryID]Entry
 	japanese *RadixTree
 	furigana *RadixTree
-	english  *InvertedIndex
+	// romaji indexes the readings of entries in romaji, folded by romajiKey
+	romaji  *RadixTree
+	english *InvertedIndex
+	kanji   map[rune]kanjidic2.Kanji
+
+	// okurigana indexes the words written with okurigana without them,
+	// folded by okuriganaKey
+	okurigana *RadixTree
+	// variants maps the EntSeq of an EDICT2 line to the entries it was
+	// split into, canonical first
+	variants map[string][]EntryID
+	// qualified maps the qualifiers of queries, such as pos:v5k, to the
+	// entries that have them
+	qualified map[string][]EntryID
+
+	// synonyms maps English terms to the terms searches for them are
+	// widened to
+	synonyms map[string][]string
+
+	substrings *SubstringIndex
+
+	components *ComponentIndex
+	examples   *ExampleIndex
 }
 
 func newEntry(entry *edict2.Entry, id uint64) *Entry {
@@ -35,14 +84,37 @@ func cleanWord(w string) string {
 	return strings.ToLower(strings.Trim(w, ",.()|`'\"!"))
 }
 
+// An EntryReader reads dictionary entries one at a time. *edict2.EDict is
+// the canonical implementation.
+type EntryReader interface {
+	Scan() bool
+	NextEntry() error
+	Entry() *edict2.Entry
+	Err() error
+}
+
+// Load reads a dictionary of JSON encoded edict2 entries from r.
 func Load(r io.Reader) (Dictionary, error) {
+	return LoadEntries(edict2.New(r))
+}
+
+// LoadEntries builds a dictionary from the entries produced by edict.
+func LoadEntries(edict EntryReader) (Dictionary, error) {
 	d := Dictionary{}
 	d.entries = map[EntryID]Entry{}
 	d.japanese = NewRadixTree()
 	d.furigana = NewRadixTree()
-	d.english = NewInvertedIndex(30)
+	d.romaji = NewRadixTree()
+	d.okurigana = NewRadixTree()
+	d.variants = map[string][]EntryID{}
+	d.qualified = map[string][]EntryID{}
+	d.english = NewInvertedIndex()
+	d.kanji = map[rune]kanjidic2.Kanji{}
+	d.components = NewComponentIndex()
+	d.examples = NewExampleIndex()
+	d.substrings = NewSubstringIndex()
+	d.synonyms = map[string][]string{}
 
-	edict := edict2.New(r)
 	var i uint64
 	for edict.Scan() {
 		i++
@@ -53,31 +125,47 @@ func Load(r io.Reader) (Dictionary, error) {
 			return d, err
 		}
 		e := newEntry(edict.Entry(), i)
+		e.Ruby = d.entryRuby(*e)
+		e.Romaji = entryRomaji(*e)
 		d.entries[e.ID] = *e
-		d.japanese.Insert(e.Japanese, e.ID)
-		d.furigana.Insert(e.Furigana, e.ID)
-
-		for _, gloss := range e.Glosses {
-			words := strings.Split(gloss.English, " ")
-			for _, w := range words {
-				d.english.Insert(cleanWord(w), e.ID, 1.0/float64(len(words)))
-			}
+		// words are indexed under their normalized forms, and keep the
+		// forms they are written in for display
+		japanese, furigana := normalizeKey(e.Japanese), normalizeKey(e.Furigana)
+		d.japanese.Insert(japanese, e.ID)
+		d.furigana.Insert(furigana, e.ID)
+		if e.Romaji != "" {
+			d.romaji.Insert(romajiKey(e.Romaji), e.ID)
+		}
+		d.substrings.Insert(japanese, e.ID)
+		d.substrings.Insert(furigana, e.ID)
+		d.addVariant(*e)
+		for _, k := range qualifierKeys(*e) {
+			d.qualified[k] = append(d.qualified[k], e.ID)
 		}
-
 	}
 	if err := edict.Err(); err != nil {
 		return d, err
 	}
+	d.substrings.Build()
+	d.SetRanking(DefaultRanking)
 
 	return d, nil
 }
 
-// Get fetches the entry with the given ID, and returns it.
+// Get fetches the entry with the given ID, and returns it along with the
+// other spellings and readings of its word.
 func (d Dictionary) Get(id EntryID) (e Entry, found bool) {
 	e, found = d.entries[id]
+	if found {
+		e = d.listVariants(e)
+	}
 	return
 }
 
+// fuzzyMinResults is the number of results below which Search adds
+// entries found by FuzzySearch.
+const fuzzyMinResults = 3
+
 type ByCommon []Entry
 
 func (a ByCommon) Len() int           { return len(a) }
@@ -85,89 +173,12 @@ func (a ByCommon) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a ByCommon) Less(i, j int) bool { return a[i].Common && !a[j].Common }
 
 // Search takes a search string provided by the user, and returns a matching
-// Entry slice with at most `limit` number of entries.
-func (d Dictionary) Search(s string, limit int) (results []Entry) {
-	results = []Entry{}
-	resultsMap := map[EntryID]bool{}
-
-	word := cleanWord(s)
-
-	appendResults := func(f func(word string, max int) []EntryID, word string, max int) {
-		if entryIDs := f(word, max); entryIDs != nil {
-			for _, eid := range entryIDs {
-				// some entries have the same Japanese and Furigana fields, so we should
-				// only add those to the results slice once
-				if _, found := resultsMap[eid]; found {
-					continue
-				}
-
-				resultsMap[eid] = true
-
-				results = append(results, d.entries[eid])
-			}
-		}
-	}
-
-	appendResults(d.japanese.FindWordsWithPrefix, word, 5)
-	appendResults(d.furigana.FindWordsWithPrefix, word, 5)
-
-	if kana.IsLatin(word) {
-		hira := kana.RomajiToHiragana(word)
-		kata := kana.RomajiToKatakana(word)
-
-		appendResults(d.furigana.FindWordsWithPrefix, hira, 5)
-		appendResults(d.furigana.FindWordsWithPrefix, kata, 5)
-	}
-
-	// build a priority queue of relevant entries for english search terms,
-	// using our inverted index, and pull out the top 10
-	words := strings.Split(s, " ")
-	scores := map[EntryID]float64{}
-	for i, w := range words {
-		// limit to 10 words, to keep the request time bounded
-		if i > 10 {
-			break
-		}
-
-		cw := cleanWord(w)
-		r := d.english.Get(cw)
-		if r == nil {
-			continue
-		}
-
-		for i := range r {
-			scores[r[i].id] += r[i].score
-			for _, w2 := range words {
-				cw2 := cleanWord(w2)
-				if w != w2 {
-					if d.english.Test(cw2, r[i].id) {
-						scores[r[i].id] *= 10 // for now double score without verifying bloom-filter correctness
-					} else {
-						scores[r[i].id] /= 10
-					}
-				}
-			}
-		}
+// Entry slice with at most `limit` number of entries. See SearchWithOptions
+// for the kinds of searches understood, and for paging.
+func (d Dictionary) Search(s string, limit int) []Entry {
+	results, err := d.SearchWithOptions(s, SearchOptions{Limit: limit})
+	if err != nil {
+		return []Entry{}
 	}
-
-	pq := make(PriorityQueue, len(scores))
-	i := 0
-	for id, priority := range scores {
-		pq[i] = &Item{
-			id:       id,
-			priority: priority,
-			index:    i,
-		}
-		i++
-	}
-
-	heap.Init(&pq)
-	// Take the items out; they arrive in decreasing priority order.
-	for pq.Len() > 0 && len(results) < 10 {
-		item := heap.Pop(&pq).(*Item)
-		results = append(results, d.entries[item.id])
-	}
-
-	sort.Sort(ByCommon(results))
-	return
+	return results.Entries
 }
@@ -4,7 +4,10 @@ import (
 	"compress/gzip"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
+
+	"github.com/gojp/nihongo/edict2"
 )
 
 func loadDict() (*Dictionary, error) {
@@ -26,6 +29,16 @@ func loadDict() (*Dictionary, error) {
 	return &dict, nil
 }
 
+// loadText builds a dictionary from raw EDICT2 lines.
+func loadText(t testing.TB, lines ...string) Dictionary {
+	t.Helper()
+	d, err := LoadEntries(edict2.NewText(strings.NewReader(strings.Join(lines, "\n"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
 var exactSearches = []struct {
 	word string
 	want int
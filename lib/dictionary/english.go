@@ -0,0 +1,168 @@
+package dictionary
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/gojp/nihongo/lib/stemmer"
+)
+
+// englishToken is a word of English text, as it is indexed and searched.
+type englishToken struct {
+	// surface is the word as written, in lowercase
+	surface string
+	// term is the base form of the word, stemmed, under which it is
+	// indexed, so that "running", "runs" and "ran" all have the term "run"
+	term string
+}
+
+// analyzeEnglish splits English text into words at spaces, punctuation and
+// hyphens, and finds the term of each. The position of a word in the
+// English index is its position in the returned slice.
+func analyzeEnglish(text string) []englishToken {
+	var tokens []englishToken
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+	for _, w := range words {
+		w = strings.TrimSuffix(strings.Trim(w, "'"), "'s")
+		if w == "" {
+			continue
+		}
+		tokens = append(tokens, englishToken{surface: w, term: englishTerm(w)})
+	}
+	return tokens
+}
+
+// englishTerm returns the term of a single lowercase word: the base form of
+// irregular forms such as "ran" or "mice", and otherwise its Porter stem.
+func englishTerm(word string) string {
+	if base, ok := irregularForms[word]; ok {
+		word = base
+	}
+	return stemmer.Stem(word)
+}
+
+// englishTerms returns the terms of the words of text.
+func englishTerms(text string) []string {
+	var terms []string
+	for _, t := range analyzeEnglish(text) {
+		terms = append(terms, t.term)
+	}
+	return terms
+}
+
+// searchTokens returns the words of an English search that are looked up
+// in the index. Stop words are left out unless the search is only made of
+// them, as in "to be".
+func searchTokens(s string) []englishToken {
+	all := analyzeEnglish(s)
+	var tokens []englishToken
+	for _, t := range all {
+		if !stopWords[t.surface] {
+			tokens = append(tokens, t)
+		}
+	}
+	if len(tokens) == 0 {
+		return all
+	}
+	return tokens
+}
+
+// stopWords are words too common in glosses to tell entries apart. They are
+// indexed, for phrases such as "to take care of", but left out of other
+// searches.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "the": true,
+	"to": true, "of": true, "in": true, "on": true, "at": true, "by": true,
+	"for": true, "with": true, "from": true, "as": true, "into": true,
+	"and": true, "or": true, "but": true,
+	"is": true, "are": true, "be": true, "was": true, "were": true,
+	"it": true, "its": true, "that": true, "this": true,
+	"e": true, "g": true, "etc": true,
+}
+
+// irregularForms maps the irregular forms of common English verbs, nouns
+// and adjectives to their base form, which the Porter stemmer cannot find.
+var irregularForms = map[string]string{
+	// verbs
+	"am": "be", "is": "be", "are": "be", "was": "be", "were": "be", "been": "be",
+	"has": "have", "had": "have",
+	"did": "do", "does": "do", "done": "do",
+	"ran":  "run",
+	"went": "go", "gone": "go", "goes": "go",
+	"ate": "eat", "eaten": "eat",
+	"saw": "see", "seen": "see",
+	"took": "take", "taken": "take",
+	"gave": "give", "given": "give",
+	"came": "come",
+	"made": "make",
+	"said": "say",
+	"got":  "get", "gotten": "get",
+	"knew": "know", "known": "know",
+	"thought":    "think",
+	"bought":     "buy",
+	"brought":    "bring",
+	"caught":     "catch",
+	"taught":     "teach",
+	"fought":     "fight",
+	"sought":     "seek",
+	"felt":       "feel",
+	"found":      "find",
+	"told":       "tell",
+	"sold":       "sell",
+	"held":       "hold",
+	"stood":      "stand",
+	"understood": "understand",
+	"wrote":      "write", "written": "write",
+	"spoke": "speak", "spoken": "speak",
+	"broke": "break", "broken": "break",
+	"chose": "choose", "chosen": "choose",
+	"drove": "drive", "driven": "drive",
+	"rode": "ride", "ridden": "ride",
+	"fallen": "fall",
+	"flew":   "fly", "flown": "fly",
+	"drew": "draw", "drawn": "draw",
+	"grew": "grow", "grown": "grow",
+	"threw": "throw", "thrown": "throw",
+	"sang": "sing", "sung": "sing",
+	"swam": "swim", "swum": "swim",
+	"began": "begin", "begun": "begin",
+	"drank": "drink", "drunk": "drink",
+	"sat":   "sit",
+	"slept": "sleep",
+	"kept":  "keep",
+	"met":   "meet",
+	"paid":  "pay",
+	"sent":  "send",
+	"spent": "spend",
+	"built": "build",
+	"lost":  "lose",
+	"heard": "hear",
+	"wore":  "wear", "worn": "wear",
+	"woke": "wake", "woken": "wake",
+	"forgot": "forget", "forgotten": "forget",
+	"hid": "hide", "hidden": "hide",
+	"dying": "die", "lying": "lie", "tying": "tie",
+
+	// nouns
+	"children": "child",
+	"men":      "man",
+	"women":    "woman",
+	"people":   "person",
+	"mice":     "mouse",
+	"feet":     "foot",
+	"teeth":    "tooth",
+	"geese":    "goose",
+	"oxen":     "ox",
+	"lice":     "louse",
+	"wives":    "wife",
+	"knives":   "knife",
+	"halves":   "half",
+	"wolves":   "wolf",
+	"selves":   "self",
+
+	// adjectives
+	"better": "good", "best": "good",
+	"worse": "bad", "worst": "bad",
+}
@@ -0,0 +1,82 @@
+package dictionary
+
+import (
+	"reflect"
+	"testing"
+)
+
+var analyzeEnglishTests = []struct {
+	text     string
+	surfaces []string
+	terms    []string
+}{
+	{"to run", []string{"to", "run"}, []string{"to", "run"}},
+	{"running; runs", []string{"running", "runs"}, []string{"run", "run"}},
+	{"ran", []string{"ran"}, []string{"run"}},
+	{"well-known (e.g. Tokyo)", []string{"well", "known", "e", "g", "tokyo"}, []string{"well", "know", "e", "g", "tokyo"}},
+	{"the cat's paw", []string{"the", "cat", "paw"}, []string{"the", "cat", "paw"}},
+	{"'hello'", []string{"hello"}, []string{"hello"}},
+	{"mice", []string{"mice"}, []string{"mous"}},
+	{"...", nil, nil},
+}
+
+func TestAnalyzeEnglish(t *testing.T) {
+	for _, tt := range analyzeEnglishTests {
+		var surfaces, terms []string
+		for _, tok := range analyzeEnglish(tt.text) {
+			surfaces = append(surfaces, tok.surface)
+			terms = append(terms, tok.term)
+		}
+		if !reflect.DeepEqual(surfaces, tt.surfaces) || !reflect.DeepEqual(terms, tt.terms) {
+			t.Errorf("analyzeEnglish(%q) = %q, %q, want %q, %q", tt.text, surfaces, terms, tt.surfaces, tt.terms)
+		}
+	}
+}
+
+func TestSearchTokens(t *testing.T) {
+	for _, tt := range []struct {
+		search string
+		want   []string
+	}{
+		{"to take care of", []string{"take", "care"}},
+		{"the cat", []string{"cat"}},
+		// a search of only stop words keeps them
+		{"to be", []string{"to", "be"}},
+	} {
+		var got []string
+		for _, tok := range searchTokens(tt.search) {
+			got = append(got, tok.surface)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("searchTokens(%q) = %q, want %q", tt.search, got, tt.want)
+		}
+	}
+}
+
+func TestEnglishForms(t *testing.T) {
+	d := loadText(t,
+		"走る [はしる] /(v5r,vi) to run/(P)/EntL1596000X/",
+		"ランニング /(n) running/(P)/EntL1145020X/",
+		"鼠 [ねずみ] /(n) mouse/rat/EntL1467300X/",
+	)
+
+	for _, tt := range []struct {
+		search string
+		want   []string
+	}{
+		// both forms are found, the one written as searched first
+		{"run", []string{"走る", "ランニング"}},
+		{"running", []string{"ランニング", "走る"}},
+		// neither is written as searched, so the shorter definition wins
+		{"ran", []string{"ランニング", "走る"}},
+		{"mice", []string{"鼠"}},
+	} {
+		var got []string
+		for _, e := range d.englishMatches(tt.search, 0, 10) {
+			got = append(got, e.Japanese)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("englishMatches(%q) = %q, want %q", tt.search, got, tt.want)
+		}
+	}
+}
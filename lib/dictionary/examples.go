@@ -0,0 +1,91 @@
+package dictionary
+
+import (
+	"io"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gojp/nihongo/tatoeba"
+)
+
+// ExampleIndex links example sentences to the entries whose words appear in
+// them.
+type ExampleIndex struct {
+	sentences []tatoeba.Sentence
+	entries   map[EntryID][]int // entry -> indices into sentences
+}
+
+func NewExampleIndex() *ExampleIndex {
+	return &ExampleIndex{
+		entries: map[EntryID][]int{},
+	}
+}
+
+// wordsIn splits s into words by repeatedly taking the longest word in the
+// japanese or furigana trees that starts at the current position, once
+// normalized, and returns the entries of the words found. Single kana are
+// skipped, since they are nearly always particles.
+func (d Dictionary) wordsIn(s string) []EntryID {
+	var ids []EntryID
+	normalized := normalizeText(s)
+	for i := 0; i < len(s); {
+		n := 0
+		var matched []EntryID
+		for _, tree := range []*RadixTree{d.japanese, d.furigana} {
+			normalized.walkPrefixes(tree, i, func(end int, found []EntryID) {
+				if end-i > n {
+					n, matched = end-i, found
+				} else if end-i == n {
+					matched = append(append([]EntryID{}, matched...), found...)
+				}
+			})
+		}
+
+		if n == 0 {
+			_, size := utf8.DecodeRuneInString(s[i:])
+			i += size
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if size < n || unicode.Is(unicode.Han, r) {
+			ids = append(ids, matched...)
+		}
+		i += n
+	}
+	return ids
+}
+
+// LoadExamples reads sentence pairs from r, and indexes every sentence under
+// the entries whose words appear in it.
+func (d Dictionary) LoadExamples(r io.Reader) error {
+	c := tatoeba.New(r)
+	for c.Scan() {
+		s := *c.Sentence()
+		idx := len(d.examples.sentences)
+		d.examples.sentences = append(d.examples.sentences, s)
+
+		added := map[EntryID]bool{}
+		for _, id := range d.wordsIn(s.Japanese) {
+			if added[id] {
+				continue
+			}
+			added[id] = true
+			d.examples.entries[id] = append(d.examples.entries[id], idx)
+		}
+	}
+	return c.Err()
+}
+
+// Examples returns at most n example sentences for the entry with the given
+// ID, in the order they appeared in the corpus.
+func (d Dictionary) Examples(id EntryID, n int) []tatoeba.Sentence {
+	var examples []tatoeba.Sentence
+	for _, idx := range d.examples.entries[id] {
+		if len(examples) >= n {
+			break
+		}
+		examples = append(examples, d.examples.sentences[idx])
+	}
+	return examples
+}
@@ -0,0 +1,54 @@
+package dictionary
+
+import (
+	"strings"
+	"testing"
+)
+
+const corpus = `猫が好きです。	I like cats.
+日本の猫はかわいい。	Japanese cats are cute.
+日本に行きたい。	I want to go to Japan.
+`
+
+var exampleTests = []struct {
+	word string
+	want []string
+}{
+	{"猫", []string{"I like cats.", "Japanese cats are cute."}},
+	{"日本", []string{"Japanese cats are cute.", "I want to go to Japan."}},
+	{"かわいい", []string{"Japanese cats are cute."}},
+	{"が", nil},
+}
+
+func TestExamples(t *testing.T) {
+	d := loadText(t,
+		"猫 [ねこ] /(n) cat/(P)/EntL1467640X/",
+		"日本 [にほん] /(n) Japan/(P)/EntL1582710X/",
+		"可愛い [かわいい] /(adj-i) cute/(P)/EntL1200080X/",
+		"が /(prt) but/EntL2028930X/",
+	)
+	if err := d.LoadExamples(strings.NewReader(corpus)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range exampleTests {
+		var ids []EntryID
+		ids = append(ids, d.japanese.Get(tt.word)...)
+		ids = append(ids, d.furigana.Get(tt.word)...)
+		if len(ids) == 0 {
+			t.Fatalf("no entry for %q", tt.word)
+		}
+
+		var got []string
+		for _, s := range d.Examples(ids[0], 5) {
+			got = append(got, s.English)
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("d.Examples(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+
+	if got := len(d.Examples(d.japanese.Get("猫")[0], 1)); got != 1 {
+		t.Errorf("len(d.Examples(%q, 1)) = %d, want %d", "猫", got, 1)
+	}
+}
@@ -0,0 +1,157 @@
+package dictionary
+
+import (
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// RubySegment is a piece of text along with its reading. Reading is empty
+// for text that needs no furigana.
+type RubySegment struct {
+	Text    string `json:"text"`
+	Reading string `json:"reading,omitempty"`
+}
+
+// FuriganaOptions controls which kanji Furigana annotates.
+type FuriganaOptions struct {
+	// Grade, if set, leaves out readings for kanji taught up to and
+	// including this school grade.
+	Grade int
+	// JLPT, if set, leaves out readings for kanji of this JLPT level or
+	// easier. KANJIDIC2 uses the old levels, from 4 (easiest) to 1.
+	JLPT int
+}
+
+// surfaceReading returns the reading of a token as it appears in the text,
+// which for conjugated words differs from the reading of its dictionary
+// form.
+func (d Dictionary) surfaceReading(t Token) string {
+	if t.Reading == "" || isKana(t.Surface) {
+		return ""
+	}
+	if len(t.Inflections) == 0 {
+		return t.Reading
+	}
+
+	for _, c := range Conjugate(d.entries[t.EntryIDs[0]]) {
+		if c.Japanese == t.Surface {
+			return c.Furigana
+		}
+	}
+
+	// swap the kana ending of the dictionary form for the one in the text
+	stem := strings.TrimRightFunc(t.Base, isKanaRune)
+	ending := toHiragana(t.Base[len(stem):])
+	if !strings.HasPrefix(t.Surface, stem) || !strings.HasSuffix(toHiragana(t.Reading), ending) {
+		return ""
+	}
+	stemReading := toHiragana(t.Reading)[:len(toHiragana(t.Reading))-len(ending)]
+	return stemReading + t.Surface[len(stem):]
+}
+
+// knownKanji reports whether every kanji in s is easy enough to leave
+// without a reading.
+func (d Dictionary) knownKanji(s string, opts FuriganaOptions) bool {
+	if opts.Grade == 0 && opts.JLPT == 0 {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.Is(unicode.Han, r) {
+			continue
+		}
+		k, found := d.kanji[r]
+		if !found {
+			return false
+		}
+		byGrade := opts.Grade > 0 && k.Grade > 0 && k.Grade <= opts.Grade
+		byJLPT := opts.JLPT > 0 && k.JLPT > 0 && k.JLPT >= opts.JLPT
+		if !byGrade && !byJLPT {
+			return false
+		}
+	}
+	return true
+}
+
+// Furigana splits text into words and annotates every run of kanji with its
+// reading, leaving out the readings of kanji that opts consider known.
+func (d Dictionary) Furigana(text string, opts FuriganaOptions) []RubySegment {
+	var segments []RubySegment
+	add := func(s RubySegment) {
+		if s.Reading != "" && d.knownKanji(s.Text, opts) {
+			s.Reading = ""
+		}
+		// merge text without readings to keep the output compact
+		if last := len(segments) - 1; s.Reading == "" && last >= 0 && segments[last].Reading == "" {
+			segments[last].Text += s.Text
+			return
+		}
+		segments = append(segments, s)
+	}
+
+	i := 0
+	for _, t := range d.Analyze(text) {
+		// Analyze drops whitespace, so copy anything it skipped
+		j := strings.Index(text[i:], t.Surface)
+		if j < 0 {
+			continue
+		}
+		if j > 0 {
+			add(RubySegment{Text: text[i : i+j]})
+		}
+		i += j + len(t.Surface)
+
+		reading := d.surfaceReading(t)
+		if reading == "" {
+			add(RubySegment{Text: t.Surface})
+			continue
+		}
+		for _, s := range d.align(t.Surface, reading) {
+			add(s)
+		}
+	}
+	if i < len(text) {
+		add(RubySegment{Text: text[i:]})
+	}
+	return segments
+}
+
+// RubyHTML renders segments as HTML, using <ruby> elements for segments
+// with a reading.
+func RubyHTML(segments []RubySegment) string {
+	var b strings.Builder
+	for _, s := range segments {
+		if s.Reading == "" {
+			b.WriteString(html.EscapeString(s.Text))
+			continue
+		}
+		b.WriteString("<ruby>")
+		b.WriteString(html.EscapeString(s.Text))
+		b.WriteString("<rp>(</rp><rt>")
+		b.WriteString(html.EscapeString(s.Reading))
+		b.WriteString("</rt><rp>)</rp></ruby>")
+	}
+	return b.String()
+}
+
+// RubyBrackets renders segments as plain text, following every segment with
+// a reading by the reading in brackets, as in 漢字[かんじ]. A space is put in
+// front of the segment when it directly follows another kanji, so it is
+// clear where the annotated text starts.
+func RubyBrackets(segments []RubySegment) string {
+	var b strings.Builder
+	for _, s := range segments {
+		if s.Reading != "" {
+			last, _ := utf8.DecodeLastRuneInString(b.String())
+			if b.Len() > 0 && unicode.Is(unicode.Han, last) {
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString(s.Text)
+		if s.Reading != "" {
+			b.WriteString("[" + s.Reading + "]")
+		}
+	}
+	return b.String()
+}
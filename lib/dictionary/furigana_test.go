@@ -0,0 +1,61 @@
+package dictionary
+
+import (
+	"strings"
+	"testing"
+)
+
+const furiganaKanjidic = `<kanjidic2>
+<character>
+<literal>日</literal>
+<misc><grade>1</grade><stroke_count>4</stroke_count><jlpt>4</jlpt></misc>
+</character>
+<character>
+<literal>本</literal>
+<misc><grade>1</grade><stroke_count>5</stroke_count><jlpt>4</jlpt></misc>
+</character>
+<character>
+<literal>猫</literal>
+<misc><grade>8</grade><stroke_count>11</stroke_count><jlpt>2</jlpt></misc>
+</character>
+</kanjidic2>`
+
+var furiganaTests = []struct {
+	text string
+	opts FuriganaOptions
+	want string
+}{
+	{"日本の猫", FuriganaOptions{}, "日本[にほん]の猫[ねこ]"},
+	{"日本の猫", FuriganaOptions{Grade: 6}, "日本の猫[ねこ]"},
+	{"日本の猫", FuriganaOptions{JLPT: 3}, "日本の猫[ねこ]"},
+	{"日本の猫", FuriganaOptions{JLPT: 1}, "日本の猫"},
+	{"日本猫", FuriganaOptions{}, "日本[にほん]猫[ねこ]"},
+	{"日本猫", FuriganaOptions{Grade: 1}, "日本 猫[ねこ]"},
+	{"猫を食べた", FuriganaOptions{}, "猫[ねこ]を食[た]べた"},
+	{"ＸＹＺ 猫", FuriganaOptions{}, "ＸＹＺ 猫[ねこ]"},
+}
+
+func TestFurigana(t *testing.T) {
+	d := loadText(t,
+		"日本 [にほん] /(n) Japan/(P)/EntL1582710X/",
+		"の /(prt) possessive particle/(P)/EntL1469800X/",
+		"を /(prt) object marker/(P)/EntL2029010X/",
+		"猫 [ねこ] /(n) cat/(P)/EntL1467640X/",
+		"食べる [たべる] /(v1,vt) to eat/(P)/EntL1358280X/",
+	)
+	if err := d.LoadKanji(strings.NewReader(furiganaKanjidic)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range furiganaTests {
+		if got := RubyBrackets(d.Furigana(tt.text, tt.opts)); got != tt.want {
+			t.Errorf("d.Furigana(%q, %+v) = %q, want %q", tt.text, tt.opts, got, tt.want)
+		}
+	}
+
+	got := RubyHTML(d.Furigana("猫<", FuriganaOptions{}))
+	want := "<ruby>猫<rp>(</rp><rt>ねこ</rt><rp>)</rp></ruby>&lt;"
+	if got != want {
+		t.Errorf("RubyHTML(...) = %q, want %q", got, want)
+	}
+}
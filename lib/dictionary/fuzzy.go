@@ -0,0 +1,145 @@
+package dictionary
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// FuzzyMatch is an entry found by FindFuzzy, along with the edit distance
+// of its key from the query.
+type FuzzyMatch struct {
+	ID       EntryID
+	Distance int
+}
+
+// nextRow computes the row of the Levenshtein table for the key extended by
+// c, given the row for the key so far.
+func nextRow(query []rune, row []int, c rune) []int {
+	next := make([]int, len(row))
+	next[0] = row[0] + 1
+	for i, q := range query {
+		cost := 1
+		if q == c {
+			cost = 0
+		}
+		next[i+1] = min(next[i]+1, row[i+1]+1, row[i]+cost)
+	}
+	return next
+}
+
+func rowMin(row []int) int {
+	m := row[0]
+	for _, v := range row[1:] {
+		m = min(m, v)
+	}
+	return m
+}
+
+// FindFuzzy returns the entries whose keys are within k edits of key, where
+// an edit inserts, deletes or replaces a single character. The tree is
+// walked one character at a time, leaving branches once every key below
+// them is known to be too far away. Matches are ordered by distance.
+func (r *RadixTree) FindFuzzy(key string, k int) []FuzzyMatch {
+	query := []rune(key)
+	first := make([]int, len(query)+1)
+	for i := range first {
+		first[i] = i
+	}
+
+	distances := map[EntryID]int{}
+
+	var walk func(n *RadixNode, partial string, row []int)
+	walk = func(n *RadixNode, partial string, row []int) {
+		if dist := row[len(query)]; partial == "" && dist <= k {
+			for _, id := range n.ids {
+				if d, found := distances[id]; !found || dist < d {
+					distances[id] = dist
+				}
+			}
+		}
+
+		for _, e := range n.edges {
+			next := row
+			rest, ok := edgeRunes(partial, e.label, func(c rune) bool {
+				next = nextRow(query, next, c)
+				return rowMin(next) <= k
+			})
+			if ok {
+				walk(e.target, rest, next)
+			}
+		}
+	}
+	walk(r.Root, "", first)
+
+	matches := make([]FuzzyMatch, 0, len(distances))
+	for id, dist := range distances {
+		matches = append(matches, FuzzyMatch{ID: id, Distance: dist})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+		return matches[i].ID < matches[j].ID
+	})
+	return matches
+}
+
+// fuzzyDistance returns the number of edits allowed for a query of n
+// characters. Queries shorter than three characters are too short to
+// correct, since nearly everything is within one edit of them.
+func fuzzyDistance(n int) int {
+	switch {
+	case n < 3:
+		return 0
+	case n < 6:
+		return 1
+	}
+	return 2
+}
+
+// FuzzySearch returns at most limit entries whose Japanese or reading is
+// close to word, allowing more edits for longer words. Latin words are read
+// as romaji. The closest entries come first, then common ones.
+func (d Dictionary) FuzzySearch(word string, limit int) []Entry {
+	queries := []string{normalizeKey(word)}
+	if isRomaji(word) {
+		queries = nil
+		for _, hira := range romajiCandidates(word) {
+			queries = append(queries, normalizeKey(hira))
+		}
+	}
+
+	distances := map[EntryID]int{}
+	for _, q := range queries {
+		k := fuzzyDistance(utf8.RuneCountInString(q))
+		if k == 0 {
+			continue
+		}
+		for _, tree := range []*RadixTree{d.japanese, d.furigana} {
+			for _, m := range tree.FindFuzzy(q, k) {
+				if dist, found := distances[m.ID]; !found || m.Distance < dist {
+					distances[m.ID] = m.Distance
+				}
+			}
+		}
+	}
+
+	results := make([]Entry, 0, len(distances))
+	for id := range distances {
+		results = append(results, d.entries[id])
+	}
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if distances[a.ID] != distances[b.ID] {
+			return distances[a.ID] < distances[b.ID]
+		}
+		if a.Common != b.Common {
+			return a.Common
+		}
+		return a.ID < b.ID
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
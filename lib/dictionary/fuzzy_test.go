@@ -0,0 +1,60 @@
+package dictionary
+
+import (
+	"testing"
+)
+
+var fuzzyTests = []struct {
+	key  string
+	k    int
+	want []FuzzyMatch
+}{
+	{"ふつう", 0, []FuzzyMatch{{3, 0}}},
+	{"ふつう", 1, []FuzzyMatch{{3, 0}, {5, 1}}},
+	{"ふすう", 1, []FuzzyMatch{{3, 1}}},
+	{"てつだい", 1, []FuzzyMatch{{1, 1}}},
+	{"普段", 1, []FuzzyMatch{{4, 1}}},
+	{"しけんん", 1, []FuzzyMatch{{0, 1}}},
+	{"あいうえお", 1, []FuzzyMatch{}},
+}
+
+func TestFindFuzzy(t *testing.T) {
+	r := NewRadixTree()
+	for i, entry := range getTests {
+		r.Insert(entry, EntryID(i))
+	}
+
+	for _, tt := range fuzzyTests {
+		got := r.FindFuzzy(tt.key, tt.k)
+		if len(got) != len(tt.want) {
+			t.Errorf("r.FindFuzzy(%q, %d) = %v, want %v", tt.key, tt.k, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("r.FindFuzzy(%q, %d) = %v, want %v", tt.key, tt.k, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestSearchFuzzy(t *testing.T) {
+	d := loadText(t,
+		"新幹線 [しんかんせん] /(n) shinkansen/bullet train/(P)/EntL1363420X/",
+		"新館 [しんかん] /(n) new building/EntL1363440X/",
+		"食べる [たべる] /(v1,vt) to eat/(P)/EntL1358280X/",
+	)
+
+	for _, s := range []string{"しんかねせん", "shinkanesn", "しんかんせn"} {
+		results := d.Search(s, 10)
+		if len(results) == 0 || results[0].Japanese != "新幹線" {
+			t.Errorf("d.Search(%q) = %v, want 新幹線 first", s, results)
+		}
+	}
+
+	// the fallback only adds words within the allowed number of edits
+	if results := d.Search("たべる", 10); len(results) != 1 {
+		t.Errorf("len(d.Search(%q)) = %d, want %d", "たべる", len(results), 1)
+	}
+}
@@ -1,43 +1,39 @@
 package dictionary
 
 import (
-	"bytes"
-	"encoding/binary"
-	"log"
 	"sort"
-
-	"github.com/gojp/nihongo/lib/bloomfilter"
 )
 
+// InvertedIndex maps keys to posting lists: every entry stored under a key,
+// with a score, in order of entry ID.
 type InvertedIndex struct {
-	MaxReferences int // maximum number of references stored per key
-	entries       map[string]*IndexEntry
+	entries map[string]*IndexEntry
 }
 
-func NewInvertedIndex(maxRef int) *InvertedIndex {
+func NewInvertedIndex() *InvertedIndex {
 	return &InvertedIndex{
-		MaxReferences: maxRef,
-		entries:       map[string]*IndexEntry{},
+		entries: map[string]*IndexEntry{},
 	}
 }
 
 type IndexEntry struct {
+	// references in increasing order of id, so that posting lists can be
+	// intersected and searched without a filter
 	references []Reference
-
-	// a bloom filter to probabilistically store references that go beyond MaxReferences
-	filter bloomfilter.BloomFilter
-}
-
-func newIndexEntry(filterSize uint) *IndexEntry {
-	return &IndexEntry{
-		references: []Reference{},
-		filter:     *bloomfilter.NewEstimated(filterSize, 0.01),
-	}
 }
 
 type Reference struct {
 	id    EntryID
 	score float64
+
+	// positions of the key in the entry's glosses, in order
+	positions []Position
+}
+
+// Position is the place of a word in the glosses of an entry.
+type Position struct {
+	Gloss int // index of the gloss
+	Word  int // index of the word in the gloss
 }
 
 // ByScore sorts references in descending order of score.
@@ -53,39 +49,48 @@ func (s ByScore) Swap(i, j int) {
 	s[i], s[j] = s[j], s[i]
 }
 
-// Less is part of sort.Interface.
+// Less is part of sort.Interface. Ties go to the lowest id.
 func (s ByScore) Less(i, j int) bool {
-	return s[i].score >= s[j].score
+	if s[i].score == s[j].score {
+		return s[i].id < s[j].id
+	}
+	return s[i].score > s[j].score
 }
 
-// Insert inserts a reference to the index
-func (i *InvertedIndex) Insert(key string, id EntryID, score float64) {
-	var (
-		ie *IndexEntry
-		ok bool
-	)
-	if ie, ok = i.entries[key]; !ok {
-		ie = newIndexEntry(1000)
+// Insert inserts a reference to the index, along with the positions of key
+// in the entry's glosses. Inserting an id again at the same key adds to its
+// score and positions.
+func (i *InvertedIndex) Insert(key string, id EntryID, score float64, positions ...Position) {
+	ie, ok := i.entries[key]
+	if !ok {
+		ie = &IndexEntry{}
 		i.entries[key] = ie
 	}
-	r := Reference{
-		id:    id,
-		score: score,
-	}
-	ie.references = append(ie.references, r)
-	sort.Sort(ByScore(ie.references)) // todo: use BST instead
-	if len(ie.references) > i.MaxReferences {
-		// throw away the last entry that exceeds the limit, but add it to the
-		// bloom filter
-		extra := ie.references[i.MaxReferences]
-		ie.references = ie.references[0:i.MaxReferences]
 
-		ie.filter.Add(getIDBytes(extra.id))
+	refs := ie.references
+	// ids are usually inserted in increasing order, so look from the end
+	n := len(refs)
+	if n == 0 || refs[n-1].id < id {
+		ie.references = append(refs, Reference{id: id, score: score, positions: positions})
+		return
 	}
+	at := sort.Search(n, func(j int) bool { return refs[j].id >= id })
+	if refs[at].id == id {
+		refs[at].score += score
+		refs[at].positions = append(refs[at].positions, positions...)
+		sort.Slice(refs[at].positions, func(a, b int) bool {
+			return refs[at].positions[a].before(refs[at].positions[b])
+		})
+		return
+	}
+	refs = append(refs, Reference{})
+	copy(refs[at+1:], refs[at:])
+	refs[at] = Reference{id: id, score: score, positions: positions}
+	ie.references = refs
 }
 
-// Get fetches a slice of references for the given key, or returns a nil
-// slice of no slice is found.
+// Get fetches the posting list of the given key, in order of id, or returns
+// a nil slice if the key is not in the index.
 func (i *InvertedIndex) Get(key string) []Reference {
 	e, ok := i.entries[key]
 	if !ok {
@@ -94,21 +99,162 @@ func (i *InvertedIndex) Get(key string) []Reference {
 	return e.references
 }
 
-// Test returns whether the given id might be contained at the key.
-// It does this probabilistically, using a bloom filter.
-func (i *InvertedIndex) Test(key string, id EntryID) bool {
-	e, ok := i.entries[key]
-	if !ok {
-		return false
+// Lookup returns the reference of id at key, and whether there is one.
+func (i *InvertedIndex) Lookup(key string, id EntryID) (Reference, bool) {
+	refs := i.Get(key)
+	at := sort.Search(len(refs), func(j int) bool { return refs[j].id >= id })
+	if at < len(refs) && refs[at].id == id {
+		return refs[at], true
+	}
+	return Reference{}, false
+}
+
+// matchLists returns the entries in at least minMatch of the posting lists,
+// in order of id, with their scores in those lists added up. A minMatch of
+// len(lists) intersects the lists, and a minMatch of 1 joins them; minMatch
+// is clamped to that range.
+func matchLists(lists [][]Reference, minMatch int) []Reference {
+	if len(lists) == 0 {
+		return nil
+	}
+	minMatch = max(1, min(minMatch, len(lists)))
+	if minMatch == len(lists) {
+		return intersect(lists)
+	}
+
+	// walk the lists together, always taking the lowest id at their heads
+	var matches []Reference
+	heads := make([]int, len(lists))
+	for {
+		next, found := EntryID(0), false
+		for k, l := range lists {
+			if heads[k] < len(l) && (!found || l[heads[k]].id < next) {
+				next, found = l[heads[k]].id, true
+			}
+		}
+		if !found {
+			return matches
+		}
+
+		r := Reference{id: next}
+		count := 0
+		for k, l := range lists {
+			if heads[k] < len(l) && l[heads[k]].id == next {
+				r.score += l[heads[k]].score
+				count++
+				heads[k]++
+			}
+		}
+		if count >= minMatch {
+			matches = append(matches, r)
+		}
+	}
+}
+
+// Union returns the references stored under any of keys, in order of id,
+// without their positions. The score of an id is the highest of its scores
+// under the keys, each multiplied by the weight of its key.
+func (i *InvertedIndex) Union(keys []string, weights []float64) []Reference {
+	scores := map[EntryID]float64{}
+	for k, key := range keys {
+		for _, r := range i.Get(key) {
+			scores[r.id] = max(scores[r.id], r.score*weights[k])
+		}
+	}
+
+	refs := make([]Reference, 0, len(scores))
+	for id, score := range scores {
+		refs = append(refs, Reference{id: id, score: score})
+	}
+	sort.Slice(refs, func(a, b int) bool { return refs[a].id < refs[b].id })
+	return refs
+}
+
+// intersect returns the references whose ids are in all lists, with their
+// scores added up. It goes through the shortest list, and searches the
+// others for its ids.
+func intersect(lists [][]Reference) []Reference {
+	shortest := 0
+	for k, l := range lists {
+		if len(l) < len(lists[shortest]) {
+			shortest = k
+		}
+	}
+
+	var matches []Reference
+	heads := make([]int, len(lists))
+outer:
+	for _, ref := range lists[shortest] {
+		r := Reference{id: ref.id}
+		for k, l := range lists {
+			// ids only grow, so the search can start where the last one ended
+			rest := l[heads[k]:]
+			at := sort.Search(len(rest), func(j int) bool { return rest[j].id >= ref.id })
+			heads[k] += at
+			if heads[k] >= len(l) {
+				break outer
+			}
+			if l[heads[k]].id != ref.id {
+				continue outer
+			}
+			r.score += l[heads[k]].score
+		}
+		matches = append(matches, r)
+	}
+	return matches
+}
+
+func (p Position) before(q Position) bool {
+	if p.Gloss != q.Gloss {
+		return p.Gloss < q.Gloss
+	}
+	return p.Word < q.Word
+}
+
+// Phrase returns the entries that have keys next to each other and in
+// order in one of their glosses, in order of id, with the scores of the
+// keys added up. The positions of the returned references are those of the
+// first key of every occurrence of the phrase.
+func (i *InvertedIndex) Phrase(keys []string) []Reference {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	lists := make([][]Reference, len(keys))
+	for k, key := range keys {
+		lists[k] = i.Get(key)
+	}
+
+	var matches []Reference
+	for _, r := range intersect(lists) {
+		refs := make([]Reference, len(keys))
+		for k, key := range keys {
+			refs[k], _ = i.Lookup(key, r.id)
+		}
+
+		var starts []Position
+		for _, p := range refs[0].positions {
+			if followedBy(refs[1:], p) {
+				starts = append(starts, p)
+			}
+		}
+		if len(starts) > 0 {
+			r.positions = starts
+			matches = append(matches, r)
+		}
 	}
-	return e.filter.Test(getIDBytes(id))
+	return matches
 }
 
-func getIDBytes(id EntryID) []byte {
-	buf := new(bytes.Buffer)
-	err := binary.Write(buf, binary.LittleEndian, id)
-	if err != nil {
-		log.Println("binary.Write failed:", err)
+// followedBy reports whether refs are at the positions right after p, one
+// after the other.
+func followedBy(refs []Reference, p Position) bool {
+	for k, r := range refs {
+		want := Position{Gloss: p.Gloss, Word: p.Word + k + 1}
+		at := sort.Search(len(r.positions), func(j int) bool { return !r.positions[j].before(want) })
+		if at == len(r.positions) || r.positions[at] != want {
+			return false
+		}
 	}
-	return buf.Bytes()
+	return true
 }
@@ -0,0 +1,106 @@
+package dictionary
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testIndex() *InvertedIndex {
+	i := NewInvertedIndex()
+	// ids out of order, and one inserted twice
+	for _, r := range []struct {
+		key   string
+		id    EntryID
+		score float64
+	}{
+		{"cat", 3, 1},
+		{"cat", 1, 1},
+		{"cat", 5, 1},
+		{"hot", 1, 2},
+		{"hot", 2, 2},
+		{"hot", 5, 2},
+		{"food", 2, 4},
+		{"food", 5, 4},
+		{"food", 5, 4},
+	} {
+		i.Insert(r.key, r.id, r.score)
+	}
+	return i
+}
+
+var matchTests = []struct {
+	keys     []string
+	minMatch int
+	want     []Reference
+}{
+	{[]string{"cat"}, 1, []Reference{{id: 1, score: 1}, {id: 3, score: 1}, {id: 5, score: 1}}},
+	{[]string{"cat", "hot", "food"}, 3, []Reference{{id: 5, score: 11}}},
+	{[]string{"hot", "food"}, 2, []Reference{{id: 2, score: 6}, {id: 5, score: 10}}},
+	{[]string{"cat", "hot", "food"}, 2, []Reference{{id: 1, score: 3}, {id: 2, score: 6}, {id: 5, score: 11}}},
+	{[]string{"cat", "hot", "food"}, 1, []Reference{{id: 1, score: 3}, {id: 2, score: 6}, {id: 3, score: 1}, {id: 5, score: 11}}},
+	{[]string{"cat", "dog"}, 2, nil},
+	{[]string{"cat", "dog"}, 1, []Reference{{id: 1, score: 1}, {id: 3, score: 1}, {id: 5, score: 1}}},
+	// minMatch is clamped to the number of keys
+	{[]string{"hot", "food"}, 5, []Reference{{id: 2, score: 6}, {id: 5, score: 10}}},
+	{[]string{"hot", "food"}, 0, []Reference{{id: 1, score: 2}, {id: 2, score: 6}, {id: 5, score: 10}}},
+}
+
+func TestMatchLists(t *testing.T) {
+	i := testIndex()
+	for _, tt := range matchTests {
+		lists := make([][]Reference, len(tt.keys))
+		for k, key := range tt.keys {
+			lists[k] = i.Get(key)
+		}
+		if got := matchLists(lists, tt.minMatch); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("matchLists(%q, %d) = %v, want %v", tt.keys, tt.minMatch, got, tt.want)
+		}
+	}
+}
+
+func TestInvertedIndexLookup(t *testing.T) {
+	i := testIndex()
+	for _, tt := range []struct {
+		key  string
+		id   EntryID
+		want bool
+	}{
+		{"cat", 1, true},
+		{"cat", 2, false},
+		{"cat", 5, true},
+		{"food", 1, false},
+		{"dog", 1, false},
+	} {
+		if _, got := i.Lookup(tt.key, tt.id); got != tt.want {
+			t.Errorf("Lookup(%q, %d) found = %t, want %t", tt.key, tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestInvertedIndexPhrase(t *testing.T) {
+	i := NewInvertedIndex()
+	// "to take care of" in entry 1, "care to take" in entry 2, and the
+	// words in different glosses of entry 3
+	i.Insert("to", 1, 1, Position{0, 0}, Position{1, 0})
+	i.Insert("take", 1, 1, Position{1, 1})
+	i.Insert("care", 1, 1, Position{1, 2})
+	i.Insert("take", 2, 1, Position{0, 2})
+	i.Insert("care", 2, 1, Position{0, 0})
+	i.Insert("to", 2, 1, Position{0, 1})
+	i.Insert("take", 3, 1, Position{0, 0})
+	i.Insert("care", 3, 1, Position{1, 0})
+
+	for _, tt := range []struct {
+		keys []string
+		want []Reference
+	}{
+		{[]string{"take", "care"}, []Reference{{id: 1, score: 2, positions: []Position{{1, 1}}}}},
+		{[]string{"to", "take", "care"}, []Reference{{id: 1, score: 3, positions: []Position{{1, 0}}}}},
+		{[]string{"care", "to", "take"}, []Reference{{id: 2, score: 3, positions: []Position{{0, 0}}}}},
+		{[]string{"care", "take"}, nil},
+	} {
+		if got := i.Phrase(tt.keys); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Phrase(%q) = %v, want %v", tt.keys, got, tt.want)
+		}
+	}
+}
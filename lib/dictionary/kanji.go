@@ -0,0 +1,38 @@
+package dictionary
+
+import (
+	"io"
+	"unicode/utf8"
+
+	"github.com/gojp/nihongo/kanjidic2"
+)
+
+// LoadKanji reads KANJIDIC2 XML from r, and indexes every kanji in it by
+// its character. The readings of entries are then aligned again, now that
+// the readings of their kanji are known.
+func (d Dictionary) LoadKanji(r io.Reader) error {
+	kd := kanjidic2.New(r)
+	for kd.Scan() {
+		k := kd.Kanji()
+		c, _ := utf8.DecodeRuneInString(k.Literal)
+		if c == utf8.RuneError {
+			continue
+		}
+		d.kanji[c] = *k
+	}
+	if err := kd.Err(); err != nil {
+		return err
+	}
+
+	for id, e := range d.entries {
+		e.Ruby = d.entryRuby(e)
+		d.entries[id] = e
+	}
+	return nil
+}
+
+// Kanji fetches the KANJIDIC2 record for the character c, and returns it.
+func (d Dictionary) Kanji(c rune) (k kanjidic2.Kanji, found bool) {
+	k, found = d.kanji[c]
+	return
+}
@@ -0,0 +1,36 @@
+package dictionary
+
+import (
+	"strings"
+	"testing"
+)
+
+const kanjidic = `<kanjidic2>
+<character>
+<literal>亜</literal>
+<misc><stroke_count>7</stroke_count></misc>
+<reading_meaning><rmgroup><reading r_type="ja_on">ア</reading><meaning>Asia</meaning></rmgroup></reading_meaning>
+</character>
+</kanjidic2>`
+
+func TestKanji(t *testing.T) {
+	d, err := Load(strings.NewReader(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.LoadKanji(strings.NewReader(kanjidic)); err != nil {
+		t.Fatal(err)
+	}
+
+	k, found := d.Kanji('亜')
+	if !found {
+		t.Fatalf("d.Kanji(%q) found = %t, want %t", '亜', found, true)
+	}
+	if k.StrokeCount != 7 {
+		t.Errorf("k.StrokeCount = %d, want %d", k.StrokeCount, 7)
+	}
+
+	if _, found := d.Kanji('唖'); found {
+		t.Errorf("d.Kanji(%q) found = %t, want %t", '唖', found, false)
+	}
+}
@@ -0,0 +1,162 @@
+package dictionary
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// The keys of the japanese and furigana trees and of the substring index
+// are normalized, so that spellings that only differ in width, script or
+// notation find the same words. Entries keep their original Japanese and
+// Furigana for display, and for ranking words written exactly as searched
+// first.
+//
+// Normalizing applies NFKC, which turns half-width katakana and full-width
+// letters and digits into their usual forms, and then
+//
+//   - lowercases letters
+//   - folds katakana into hiragana
+//   - folds the small vowels ぁぃぅぇぉ and ゎ into full-size kana, and ヵ and
+//     ヶ, as in 一ヶ月, into か; っゃゅょ are left alone, since they change
+//     how a word is read
+//   - spells out ー as the vowel before it, so that らーめん is らあめん
+//   - spells out the iteration marks 々, ゝ and ゞ as the character before
+//     them, so that 人々 is 人人 and いすゞ is いすず
+
+// normalizeKey returns s normalized as the keys of the trees are.
+func normalizeKey(s string) string {
+	return normalizeText(s).key
+}
+
+// normalizedText is a text normalized as the keys of the trees are, along
+// with the byte offsets that map between the two. Offsets within a
+// character that normalizing changed into several, or several into one,
+// have no counterpart and map to -1.
+type normalizedText struct {
+	key        string
+	toSource   []int // len(key)+1 offsets into the source text
+	fromSource []int // len(source)+1 offsets into key
+}
+
+func normalizeText(s string) normalizedText {
+	t := normalizedText{
+		toSource:   []int{0},
+		fromSource: make([]int, len(s)+1),
+	}
+	for i := range t.fromSource {
+		t.fromSource[i] = -1
+	}
+	t.fromSource[0] = 0
+
+	var b strings.Builder
+	var prev rune
+	for i := 0; i < len(s); {
+		// normalize a character along with the marks that combine with it
+		n := norm.NFKC.NextBoundaryInString(s[i:], true)
+		segment := norm.NFKC.String(s[i : i+n])
+		for _, r := range segment {
+			r = foldRune(r, prev)
+			b.WriteRune(r)
+			for j := 0; j < utf8.RuneLen(r); j++ {
+				t.toSource = append(t.toSource, -1)
+			}
+			prev = r
+		}
+		i += n
+		if segment != "" {
+			t.toSource[len(t.toSource)-1] = i
+		}
+		t.fromSource[i] = b.Len()
+	}
+	t.key = b.String()
+	return t
+}
+
+// foldRune returns the normalized form of r, which follows the normalized
+// rune prev.
+func foldRune(r, prev rune) rune {
+	r = unicode.ToLower(r)
+	switch {
+	case r >= 'ァ' && r <= 'ヶ', r == 'ヽ' || r == 'ヾ':
+		// katakana are 0x60 after the matching hiragana
+		r -= 0x60
+	}
+
+	switch r {
+	case 'ぁ', 'ぃ', 'ぅ', 'ぇ', 'ぉ', 'ゎ':
+		return r + 1
+	case 'ゕ', 'ゖ':
+		return 'か'
+	case 'ー':
+		if v, ok := kanaVowels[prev]; ok {
+			return v
+		}
+	case '々':
+		if prev != 0 {
+			return prev
+		}
+	case 'ゝ':
+		if v, ok := unvoiced[prev]; ok {
+			return v
+		}
+		if isKana(string(prev)) {
+			return prev
+		}
+	case 'ゞ':
+		if v, ok := rendaku[prev]; ok {
+			return v[0]
+		}
+		if _, ok := unvoiced[prev]; ok {
+			return prev
+		}
+	}
+	return r
+}
+
+// kanaVowels maps hiragana to the vowel they end with.
+var kanaVowels = map[rune]rune{}
+
+// unvoiced maps voiced hiragana to their unvoiced forms.
+var unvoiced = map[rune]rune{}
+
+func init() {
+	for vowel, row := range map[rune]string{
+		'あ': "あかがさざただなはばぱまやらわゃ",
+		'い': "いきぎしじちぢにひびぴみりゐ",
+		'う': "うくぐすずつづぬふぶぷむゆるゔゅ",
+		'え': "えけげせぜてでねへべぺめれゑ",
+		'お': "おこごそぞとどのほぼぽもよろをょ",
+	} {
+		for _, r := range row {
+			kanaVowels[r] = vowel
+		}
+	}
+	for r, voiced := range rendaku {
+		// じ and ず are far more often voiced し and す than ち and つ
+		if r == 'ち' || r == 'つ' {
+			voiced = voiced[:1]
+		}
+		for _, v := range voiced {
+			unvoiced[v] = r
+		}
+	}
+}
+
+// walkPrefixes calls fn for every key in tree that the text has at the
+// source offset start, passing the end of the key in the source text along
+// with its entries. Keys that end inside a character of the source text
+// are skipped.
+func (t normalizedText) walkPrefixes(tree *RadixTree, start int, fn func(end int, ids []EntryID)) {
+	from := t.fromSource[start]
+	if from < 0 {
+		return
+	}
+	tree.WalkPrefixes(t.key[from:], func(n int, ids []EntryID) {
+		if end := t.toSource[from+n]; end > 0 {
+			fn(end, ids)
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package dictionary
+
+import (
+	"reflect"
+	"testing"
+)
+
+var normalizeTests = []struct {
+	s    string
+	want string
+}{
+	{"かたかな", "かたかな"},
+	{"カタカナ", "かたかな"},
+	{"ｶﾀｶﾅ", "かたかな"},
+	{"ｶﾞｯｺｳ", "がっこう"},
+	{"ｔｏｋｙｏ", "tokyo"},
+	{"Tokyo", "tokyo"},
+	{"１２３", "123"},
+	{"ラーメン", "らあめん"},
+	{"コーヒー", "こおひい"},
+	{"ニュース", "にゅうす"},
+	{"ーん", "ーん"},
+	{"ファイル", "ふあいる"},
+	{"一ヶ月", "一か月"},
+	{"一ヵ月", "一か月"},
+	{"きって", "きって"},
+	{"きゃく", "きゃく"},
+	{"人々", "人人"},
+	{"こゝろ", "こころ"},
+	{"いすゞ", "いすず"},
+	{"ぶゝ", "ぶふ"},
+}
+
+func TestNormalizeKey(t *testing.T) {
+	for _, tt := range normalizeTests {
+		if got := normalizeKey(tt.s); got != tt.want {
+			t.Errorf("normalizeKey(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizedTextWalkPrefixes(t *testing.T) {
+	tree := NewRadixTree()
+	tree.Insert(normalizeKey("ガ"), 1)
+	tree.Insert(normalizeKey("ガッコウ"), 2)
+
+	text := "ｶﾞｯｺｳへ"
+	var got []string
+	normalizeText(text).walkPrefixes(tree, 0, func(end int, ids []EntryID) {
+		got = append(got, text[:end])
+	})
+	if want := []string{"ｶﾞ", "ｶﾞｯｺｳ"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("prefixes of %q = %q, want %q", text, got, want)
+	}
+}
+
+var normalizeLines = []string{
+	"片仮名 [かたかな] /(n) katakana/(P)/EntL1081380X/",
+	"カタカナ /(n) katakana/EntL1081390X/",
+	"ラーメン /(n) ramen/(P)/EntL1138220X/",
+	"一ヶ月 [いっかげつ] /(n) one month/(P)/EntL1161190X/",
+	"人々 [ひとびと] /(n) people/(P)/EntL1580460X/",
+	"東京 [とうきょう] /(n) Tokyo/(P)/EntL1444140X/",
+}
+
+var normalizedSearches = []struct {
+	s    string
+	want string
+}{
+	{"ﾗｰﾒﾝ", "ラーメン"},
+	{"らあめん", "ラーメン"},
+	{"一か月", "一ヶ月"},
+	{"人人", "人々"},
+	{"ﾋﾄﾋﾞﾄ", "人々"},
+	{"ｔｏｕｋｙｏｕ", "東京"},
+	{"ＴＯＫＹＯ", "東京"},
+
+	// words written as searched come before those that only match once
+	// normalized, even if those are common
+	{"かたかな", "片仮名"},
+	{"カタカナ", "カタカナ"},
+	{"ｶﾀｶﾅ", "カタカナ"},
+}
+
+func TestSearchNormalized(t *testing.T) {
+	d := loadText(t, normalizeLines...)
+	for _, tt := range normalizedSearches {
+		entries := d.Search(tt.s, 10)
+		if len(entries) == 0 || entries[0].Japanese != tt.want {
+			var got []string
+			for _, e := range entries {
+				got = append(got, e.Japanese)
+			}
+			t.Errorf("d.Search(%q) = %q, want %q first", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeNormalized(t *testing.T) {
+	d := loadText(t, normalizeLines...)
+	tokens := d.Analyze("ﾗｰﾒﾝ")
+	if len(tokens) != 1 || tokens[0].Surface != "ﾗｰﾒﾝ" || tokens[0].Base != "ラーメン" {
+		t.Errorf("d.Analyze(%q) = %+v, want ラーメン", "ﾗｰﾒﾝ", tokens)
+	}
+}
@@ -17,6 +17,10 @@ func (pq PriorityQueue) Len() int { return len(pq) }
 
 func (pq PriorityQueue) Less(i, j int) bool {
 	// We want Pop to give us the highest, not lowest, priority so we use greater than here.
+	// Ties go to the lowest id, so that the order does not depend on map iteration.
+	if pq[i].priority == pq[j].priority {
+		return pq[i].id < pq[j].id
+	}
 	return pq[i].priority > pq[j].priority
 }
 
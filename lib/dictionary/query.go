@@ -0,0 +1,485 @@
+package dictionary
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// A QueryNode is a node of a parsed query, which an entry either matches or
+// not.
+type QueryNode interface {
+	Match(e Entry) bool
+}
+
+// AndNode matches entries that match all of its nodes. A parsed query is
+// always an AndNode.
+type AndNode []QueryNode
+
+// NotNode matches entries that do not match its node, as in -arch.
+type NotNode struct {
+	Node QueryNode
+}
+
+// TextNode matches entries whose Japanese or reading starts with Text, or
+// that have a form of Text as a word of their English definition.
+type TextNode struct {
+	Text string
+}
+
+// PhraseNode matches entries that have the words of a quoted phrase, in
+// order, in one of their glosses. Words holds the terms of the words, as
+// found by the English analyzer.
+type PhraseNode struct {
+	Words []string
+}
+
+// QualifierNode matches entries whose metadata has Value for the qualifier
+// Name, as in pos:v5 or common:true.
+type QualifierNode struct {
+	Name  string
+	Value string
+}
+
+// QueryError describes a query that could not be parsed.
+type QueryError struct {
+	// Offset is the position of Term in the query, in bytes
+	Offset  int    `json:"offset"`
+	Term    string `json:"term"`
+	Message string `json:"message"`
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("query: %s at offset %d: %q", e.Message, e.Offset, e.Term)
+}
+
+// qualifiers lists the qualifiers understood in queries, along with a
+// description of their values.
+var qualifiers = map[string]string{
+	"pos":      "a part of speech, such as v5 or adj-i",
+	"common":   "true or false",
+	"field":    "a field of use, such as comp or med",
+	"tag":      "a usage tag, such as arch or uk",
+	"dialect":  "a dialect, such as ksb",
+	"kanjitag": "a tag on the kanji, such as ateji or iK",
+}
+
+func qualifierNames() []string {
+	var names []string
+	for name := range qualifiers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsStructuredQuery reports whether s uses the query language understood
+// by ParseQuery, that is whether it has a qualifier, a quoted phrase or a
+// negated term. Colons in other words, as in 12:00, are not qualifiers.
+func IsStructuredQuery(s string) bool {
+	for _, f := range strings.Fields(s) {
+		if (strings.HasPrefix(f, "-") && len(f) > 1) || strings.HasPrefix(f, `"`) {
+			return true
+		}
+		if _, _, ok := cutQualifier(f); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// cutQualifier splits a term of the form name:value, where name is a word
+// of ASCII letters, such as pos in pos:v5.
+func cutQualifier(term string) (name, value string, ok bool) {
+	name, value, found := strings.Cut(term, ":")
+	if !found || name == "" {
+		return "", "", false
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			return "", "", false
+		}
+	}
+	return name, value, true
+}
+
+// ParseQuery parses a query made of terms separated by spaces, all of which
+// must match. A term is a word, a "quoted phrase", or a qualifier such as
+// pos:v5, common:true, field:comp, tag:arch, dialect:ksb or kanjitag:ateji.
+// A term prefixed by - matches the entries the term does not match.
+func ParseQuery(s string) (AndNode, error) {
+	var query AndNode
+	for i := 0; i < len(s); {
+		if unicode.IsSpace(rune(s[i])) {
+			i++
+			continue
+		}
+
+		start := i
+		negate := s[i] == '-'
+		if negate {
+			i++
+		}
+
+		var node QueryNode
+		if i < len(s) && s[i] == '"' {
+			end := strings.IndexByte(s[i+1:], '"')
+			if end < 0 {
+				return nil, &QueryError{Offset: start, Term: s[start:], Message: "unterminated phrase"}
+			}
+			phrase := s[i+1 : i+1+end]
+			i += end + 2
+
+			words := englishTerms(phrase)
+			if len(words) == 0 {
+				return nil, &QueryError{Offset: start, Term: s[start:i], Message: "empty phrase"}
+			}
+			node = PhraseNode{Words: words}
+		} else {
+			end := strings.IndexFunc(s[i:], unicode.IsSpace)
+			if end < 0 {
+				end = len(s) - i
+			}
+			term := s[i : i+end]
+			i += end
+
+			var err error
+			if node, err = parseTerm(term); err != nil {
+				err.(*QueryError).Offset = start
+				return nil, err
+			}
+		}
+
+		if node == nil {
+			continue
+		}
+		if negate {
+			node = NotNode{Node: node}
+		}
+		query = append(query, node)
+	}
+
+	if len(query) == 0 {
+		return nil, &QueryError{Term: s, Message: "empty query"}
+	}
+	return query, nil
+}
+
+// parseTerm parses a single word or qualifier. It returns a nil node for
+// words that are only punctuation.
+func parseTerm(term string) (QueryNode, error) {
+	name, value, ok := cutQualifier(term)
+	if !ok {
+		if w := cleanWord(term); w != "" {
+			return TextNode{Text: w}, nil
+		}
+		return nil, nil
+	}
+
+	name = strings.ToLower(name)
+	if _, known := qualifiers[name]; !known {
+		return nil, &QueryError{
+			Term:    term,
+			Message: fmt.Sprintf("unknown qualifier %q, want one of %s", name, strings.Join(qualifierNames(), ", ")),
+		}
+	}
+	if value == "" {
+		return nil, &QueryError{Term: term, Message: fmt.Sprintf("missing value for %s, want %s", name, qualifiers[name])}
+	}
+	if name == "common" && value != "true" && value != "false" {
+		return nil, &QueryError{Term: term, Message: fmt.Sprintf("invalid value %q for common, want true or false", value)}
+	}
+	return QualifierNode{Name: name, Value: value}, nil
+}
+
+// Match reports whether e matches every node of q.
+func (q AndNode) Match(e Entry) bool {
+	for _, n := range q {
+		if !n.Match(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// Match reports whether e does not match n.Node.
+func (n NotNode) Match(e Entry) bool {
+	return !n.Node.Match(e)
+}
+
+// kanaForms returns the normalized keys of s to look for among Japanese
+// words, which for romaji include its kana spellings.
+func kanaForms(s string) []string {
+	forms := []string{normalizeKey(s)}
+	if isRomaji(s) {
+		for _, hira := range romajiCandidates(s) {
+			forms = append(forms, normalizeKey(hira))
+		}
+	}
+	return forms
+}
+
+// Match reports whether e's Japanese or reading starts with n.Text, once
+// both are normalized, or its English definition has a word with the same
+// term.
+func (n TextNode) Match(e Entry) bool {
+	japanese, furigana := normalizeKey(e.Japanese), normalizeKey(e.Furigana)
+	for _, f := range kanaForms(n.Text) {
+		if strings.HasPrefix(japanese, f) || strings.HasPrefix(furigana, f) {
+			return true
+		}
+	}
+	term := englishTerm(n.Text)
+	for _, g := range e.Glosses {
+		if contains(englishTerms(g.English), term) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether one of e's glosses has the words of the phrase in
+// order.
+func (n PhraseNode) Match(e Entry) bool {
+	return len(n.glosses(e)) > 0
+}
+
+// glosses returns the indexes of e's glosses that have the phrase.
+func (n PhraseNode) glosses(e Entry) []int {
+	var found []int
+	for gi, g := range e.Glosses {
+		words := englishTerms(g.English)
+		for i := 0; i+len(n.Words) <= len(words); i++ {
+			if equalWords(words[i:i+len(n.Words)], n.Words) {
+				found = append(found, gi)
+				break
+			}
+		}
+	}
+	return found
+}
+
+func equalWords(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// appendMissing appends the values of add that are not in list yet.
+func appendMissing(list []int, add ...int) []int {
+	for _, v := range add {
+		if !slices.Contains(list, v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesPosCode reports whether the part of speech code matches value,
+// either exactly or as its group, so that v5 matches v5k and v1 matches
+// v1-s.
+func matchesPosCode(code, value string) bool {
+	if code == value || strings.HasPrefix(code, value+"-") {
+		return true
+	}
+	last := value[len(value)-1]
+	return last >= '0' && last <= '9' && strings.HasPrefix(code, value)
+}
+
+// qualifierValues returns the values e has for the qualifier name. Parts
+// of speech, fields, tags and dialects are looked up both on the entry and
+// on its glosses.
+func qualifierValues(e Entry, name string) []string {
+	var values []string
+	switch name {
+	case "common":
+		values = []string{fmt.Sprint(e.Common)}
+	case "pos":
+		values = append(values, e.Pos...)
+		for _, g := range e.Glosses {
+			values = append(values, g.Pos...)
+		}
+	case "field":
+		values = append(values, e.Fields...)
+		for _, g := range e.Glosses {
+			if g.Field != nil {
+				values = append(values, *g.Field)
+			}
+		}
+	case "tag":
+		values = append(values, e.Tags...)
+		for _, g := range e.Glosses {
+			values = append(values, g.Tags...)
+		}
+	case "dialect":
+		values = append(values, e.Dialects...)
+		for _, g := range e.Glosses {
+			values = append(values, g.Dialects...)
+		}
+	case "kanjitag":
+		values = e.KanjiTags
+	}
+	return values
+}
+
+// qualifierKeys returns the keys e is indexed under for queries that only
+// filter on metadata, such as pos:v5k or common:true.
+func qualifierKeys(e Entry) []string {
+	var keys []string
+	for _, name := range qualifierNames() {
+		for _, v := range qualifierValues(e, name) {
+			if k := name + ":" + v; !contains(keys, k) {
+				keys = append(keys, k)
+			}
+		}
+	}
+	return keys
+}
+
+// Match reports whether e's metadata has n.Value for the qualifier n.Name.
+func (n QualifierNode) Match(e Entry) bool {
+	values := qualifierValues(e, n.Name)
+	if n.Name == "pos" {
+		return hasPos(values, func(p string) bool { return matchesPosCode(p, n.Value) })
+	}
+	return contains(values, n.Value)
+}
+
+// entries returns the IDs of the entries that match n, in order, from the
+// index of qualifiers.
+func (n QualifierNode) entries(d Dictionary) []EntryID {
+	if n.Name != "pos" {
+		return d.qualified[n.Name+":"+n.Value]
+	}
+	// a part of speech matches its group, so v5 is looked up under v5k,
+	// v5s and the rest
+	var ids []EntryID
+	for k, list := range d.qualified {
+		if code, ok := strings.CutPrefix(k, "pos:"); ok && matchesPosCode(code, n.Value) {
+			ids = append(ids, list...)
+		}
+	}
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
+
+// maxQueryCandidates bounds the number of entries looked up in the radix
+// trees for a word of a query.
+const maxQueryCandidates = 1000
+
+// maxQueryScan bounds the number of entries a query is checked against,
+// which for a query such as common:false or -arch may be most of the
+// dictionary.
+const maxQueryScan = 20000
+
+// candidates returns the entries that may match q: those found for its
+// first word or phrase in the radix trees and the English index, or for its
+// first qualifier in the index of qualifiers, or every entry if q only has
+// negated terms. complete is false if some of the entries that may match
+// were left out, to keep within maxQueryCandidates and maxQueryScan.
+func (d Dictionary) candidates(q AndNode) (entries []Entry, complete bool) {
+	var ids []EntryID
+	found := false
+	complete = true
+	for _, n := range q {
+		switch n := n.(type) {
+		case TextNode:
+			for _, f := range kanaForms(n.Text) {
+				for _, tree := range []*RadixTree{d.japanese, d.furigana} {
+					words := tree.FindWordsWithPrefix(f, maxQueryCandidates)
+					complete = complete && len(words) < maxQueryCandidates
+					ids = append(ids, words...)
+				}
+			}
+			for _, r := range d.english.Get(englishTerm(n.Text)) {
+				ids = append(ids, r.id)
+			}
+			found = true
+		case PhraseNode:
+			for _, r := range d.english.Phrase(n.Words) {
+				ids = append(ids, r.id)
+			}
+			found = true
+		}
+		if found {
+			break
+		}
+	}
+	if !found {
+		for _, n := range q {
+			if n, ok := n.(QualifierNode); ok {
+				ids = n.entries(d)
+				found = true
+				break
+			}
+		}
+	}
+	if !found {
+		// entry IDs run from 1 without gaps
+		for id := 1; id <= len(d.entries); id++ {
+			ids = append(ids, EntryID(id))
+			if len(ids) > maxQueryScan {
+				break
+			}
+		}
+	}
+
+	added := map[EntryID]bool{}
+	for _, id := range ids {
+		if added[id] {
+			continue
+		}
+		if len(added) == maxQueryScan {
+			return entries, false
+		}
+		added[id] = true
+		entries = append(entries, d.entries[id])
+	}
+	return entries, complete
+}
+
+// Query returns at most limit entries matching q, common entries first.
+// The glosses that have the phrases of q are marked on the results.
+func (d Dictionary) Query(q AndNode, limit int) []Entry {
+	results, _ := d.query(q, limit)
+	return results
+}
+
+// query is Query, also reporting whether every entry that matches q was
+// found, or only those among the candidates it was checked against.
+func (d Dictionary) query(q AndNode, limit int) (results []Entry, complete bool) {
+	candidates, complete := d.candidates(q)
+	results = []Entry{}
+	for _, e := range candidates {
+		if !q.Match(e) {
+			continue
+		}
+		for _, n := range q {
+			if p, ok := n.(PhraseNode); ok {
+				e.MatchedGlosses = appendMissing(e.MatchedGlosses, p.glosses(e)...)
+			}
+		}
+		results = append(results, e)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Common && !results[j].Common
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, complete
+}
@@ -0,0 +1,188 @@
+package dictionary
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var parseQueryTests = []struct {
+	query string
+	want  AndNode
+}{
+	{"cat", AndNode{TextNode{"cat"}}},
+	{`pos:v5 common:true field:comp "network"`, AndNode{
+		QualifierNode{"pos", "v5"},
+		QualifierNode{"common", "true"},
+		QualifierNode{"field", "comp"},
+		PhraseNode{[]string{"network"}},
+	}},
+	{`-tag:arch -"to go" 猫`, AndNode{
+		NotNode{QualifierNode{"tag", "arch"}},
+		NotNode{PhraseNode{[]string{"to", "go"}}},
+		TextNode{"猫"},
+	}},
+	{"  Dog  ", AndNode{TextNode{"dog"}}},
+	{"common:true 12:00", AndNode{QualifierNode{"common", "true"}, TextNode{"12:00"}}},
+}
+
+func TestParseQuery(t *testing.T) {
+	for _, tt := range parseQueryTests {
+		got, err := ParseQuery(tt.query)
+		if err != nil {
+			t.Errorf("ParseQuery(%q) error = %v", tt.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseQuery(%q) = %#v, want %#v", tt.query, got, tt.want)
+		}
+	}
+}
+
+var queryErrorTests = []struct {
+	query  string
+	offset int
+	term   string
+}{
+	{"cat colour:red", 4, "colour:red"},
+	{"common:maybe", 0, "common:maybe"},
+	{"dog pos:", 4, "pos:"},
+	{`"to go`, 0, `"to go`},
+	{"", 0, ""},
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	for _, tt := range queryErrorTests {
+		_, err := ParseQuery(tt.query)
+		var qe *QueryError
+		if !errors.As(err, &qe) {
+			t.Errorf("ParseQuery(%q) error = %v, want a *QueryError", tt.query, err)
+			continue
+		}
+		if qe.Offset != tt.offset || qe.Term != tt.term {
+			t.Errorf("ParseQuery(%q) error at %d %q, want at %d %q", tt.query, qe.Offset, qe.Term, tt.offset, tt.term)
+		}
+	}
+}
+
+func TestIsStructuredQuery(t *testing.T) {
+	for s, want := range map[string]bool{
+		"cat":          false,
+		"to go":        false,
+		"pos:v5":       true,
+		`"to go"`:      true,
+		"cat -dog":     true,
+		"ko-hi-":       false,
+		"猫":            false,
+		"common:false": true,
+		"12:00":        false,
+		"ねこ：":          false,
+		"ねこ:":          false,
+	} {
+		if got := IsStructuredQuery(s); got != want {
+			t.Errorf("IsStructuredQuery(%q) = %t, want %t", s, got, want)
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	d := loadText(t,
+		"行く [いく] /(v5k-s,vi) (1) to go/(2) to proceed/(P)/EntL1578850X/",
+		"書く [かく] /(v5k,vt) to write/(P)/EntL1382750X/",
+		"食べる [たべる] /(v1,vt) to eat/(P)/EntL1358280X/",
+		"網 [あみ] /(n) net/network/(P)/EntL1536920X/",
+		"ネットワーク /(n) {comp} network/(P)/EntL1136880X/",
+		"汝 [なんじ] /(pn,arch) thou/EntL1467440X/",
+		"貴方 [あなた] /(pn) you/(P)/EntL1223615X/",
+	)
+
+	for _, tt := range []struct {
+		query string
+		want  string
+	}{
+		{"pos:v5", "行く 書く"},
+		{"pos:v5 common:true", "行く 書く"},
+		{`field:comp "network"`, "ネットワーク"},
+		{"network -field:comp", "網"},
+		{"pos:pn", "貴方 汝"},
+		{"pos:pn -tag:arch", "貴方"},
+		{"common:false", "汝"},
+		{`"to go"`, "行く"},
+		{`"go to"`, ""},
+		{"pos:v1 ta", "食べる"},
+	} {
+		q, err := ParseQuery(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, e := range d.Query(q, 10) {
+			got = append(got, e.Japanese)
+		}
+		if g := strings.Join(got, " "); g != tt.want {
+			t.Errorf("d.Query(%q) = %q, want %q", tt.query, g, tt.want)
+		}
+	}
+}
+
+func TestQueryMatchedGlosses(t *testing.T) {
+	d := loadText(t,
+		"行く [いく] /(v5k-s,vi) (1) to go/(2) to proceed/(3) to go on/(P)/EntL1578850X/",
+	)
+
+	q, err := ParseQuery(`"to proceed"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := d.Query(q, 10)
+	if len(got) != 1 || !reflect.DeepEqual(got[0].MatchedGlosses, []int{1}) {
+		t.Errorf("d.Query(%q) = %v, want 行く with matched glosses [1]", `"to proceed"`, got)
+	}
+}
+
+func TestSearchColonsArePlain(t *testing.T) {
+	d := loadText(t,
+		"猫 [ねこ] /(n) cat/(P)/EntL1467640X/",
+		"十二時 [じゅうにじ] /(n) 12:00/twelve o'clock/EntL2833900X/",
+	)
+	for s, want := range map[string]string{"ねこ：": "猫", "ねこ:": "猫", "12:00": "十二時"} {
+		r, err := d.SearchWithOptions(s, SearchOptions{})
+		if err != nil {
+			t.Errorf("d.SearchWithOptions(%q) error = %v", s, err)
+			continue
+		}
+		if len(r.Entries) == 0 || r.Entries[0].Japanese != want {
+			t.Errorf("d.SearchWithOptions(%q) = %v, want %q first", s, r.Entries, want)
+		}
+	}
+}
+
+func TestSearchQueryTruncated(t *testing.T) {
+	lines := []string{"汝 [なんじ] /(pn,arch) thou/EntL1467440X/"}
+	for i := 0; i < maxQueryScan; i++ {
+		lines = append(lines, fmt.Sprintf("語%d [ご] /(n) word %d/EntL%dX/", i, i, 3000000+i))
+	}
+	d := loadText(t, lines...)
+
+	for _, tt := range []struct {
+		query     string
+		total     int
+		truncated bool
+	}{
+		// found through the index of qualifiers
+		{"tag:arch", 1, false},
+		{"pos:pn", 1, false},
+		// negations alone are checked against the first entries only
+		{"-tag:arch", maxQueryScan - 1, true},
+	} {
+		r, err := d.SearchWithOptions(tt.query, SearchOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Total != tt.total || r.Truncated != tt.truncated {
+			t.Errorf("d.SearchWithOptions(%q) total = %d, truncated = %t, want %d, %t", tt.query, r.Total, r.Truncated, tt.total, tt.truncated)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package dictionary
 import (
 	"fmt"
 	"strings"
+	"unicode/utf8"
 )
 
 type RadixTree struct {
@@ -178,33 +179,61 @@ func (r *RadixTree) FindWordsWithPrefix(key string, max int) []EntryID {
 			children := n.FindPrefixedEntries(max - len(words))
 			words = append(words, children...)
 		} else {
-			// check if an outgoing edge shares a prefix with us
+			// the key ends partway along an outgoing edge
 			suffix := key[elementsFound:]
-			prefix := ""
-			sharedEdge := -1
-
 			for i := range n.edges {
-			inner:
-				for u := 0; u < len(n.edges[i].label) && u < len(suffix); u++ {
-					if n.edges[i].label[u] == suffix[u] {
-						prefix += suffix[u : u+1]
-					} else {
-						break inner
-					}
-				}
-				// there can be at most one outgoing edge that shares a prefix
-				if len(prefix) > 0 {
-					sharedEdge = i
+				if strings.HasPrefix(n.edges[i].label, suffix) {
+					children := n.edges[i].target.FindPrefixedEntries(max - len(words))
+					words = append(words, children...)
 					break
 				}
 			}
-
-			if sharedEdge >= 0 {
-				children := n.edges[sharedEdge].target.FindPrefixedEntries(max - len(words))
-				words = append(words, children...)
-			}
 		}
 	}
 
 	return words
 }
+
+// edgeRunes calls fn with each character of an edge label, following the
+// bytes partial carried over from the edges before it. Keys are split into
+// labels at any byte, so a label may end partway through a character; the
+// bytes of that character are returned, to be carried over to the next
+// edge. It stops, returning ok false, as soon as fn returns false.
+func edgeRunes(partial, label string, fn func(c rune) bool) (rest string, ok bool) {
+	s := partial + label
+	for utf8.FullRuneInString(s) {
+		c, size := utf8.DecodeRuneInString(s)
+		if !fn(c) {
+			return s, false
+		}
+		s = s[size:]
+	}
+	return s, true
+}
+
+// WalkPrefixes calls fn for every key in the tree that is a prefix of s,
+// from shortest to longest, passing the length of the key in bytes and the
+// entries stored under it.
+func (r *RadixTree) WalkPrefixes(s string, fn func(n int, ids []EntryID)) {
+	n := r.Root
+	consumed := 0
+	for n != nil {
+		if n.IsLeaf() && consumed > 0 {
+			fn(consumed, n.Value())
+		}
+
+		suffix := s[consumed:]
+		var next *RadixEdge
+		for i := range n.edges {
+			if strings.HasPrefix(suffix, n.edges[i].label) {
+				next = &n.edges[i]
+				break
+			}
+		}
+		if next == nil {
+			return
+		}
+		n = next.target
+		consumed += len(next.label)
+	}
+}
@@ -54,3 +54,36 @@ func TestFindWordsWithPrefix(t *testing.T) {
 		t.Fatalf("%q len(got) = %d, want %d", "ふ", got, 2)
 	}
 }
+
+// walkPrefixesTests lists the longest key walked for each string, which
+// WalkPrefixes reaches last.
+var walkPrefixesTests = []struct {
+	s    string
+	want string
+}{
+	{"普通です", "普通"},
+	{"てつだうよ", "てつだう"},
+	{"ふつうう", "ふつう"},
+	{"ふ", ""},
+	{"テスト", ""},
+}
+
+func TestWalkPrefixes(t *testing.T) {
+	r := NewRadixTree()
+	for i, entry := range getTests {
+		r.Insert(entry, EntryID(i))
+	}
+
+	for _, tt := range walkPrefixesTests {
+		got := ""
+		r.WalkPrefixes(tt.s, func(n int, ids []EntryID) {
+			if len(ids) == 0 {
+				t.Errorf("r.WalkPrefixes(%q) walked %q without ids", tt.s, tt.s[:n])
+			}
+			got = tt.s[:n]
+		})
+		if got != tt.want {
+			t.Errorf("r.WalkPrefixes(%q) walked %q last, want %q", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package dictionary
+
+import (
+	"math"
+)
+
+// TermStats describes a word of an entry's English definitions, and how
+// common it is across all entries. A RankingFunction weighs the word from
+// it.
+type TermStats struct {
+	// Count is the number of times the word appears in the entry's glosses
+	Count int
+	// Length is the number of words in the entry's glosses
+	Length int
+	// AvgLength is the average Length over all entries
+	AvgLength float64
+	// Entries is the number of entries with the word in their glosses
+	Entries int
+	// Total is the number of entries with glosses
+	Total int
+}
+
+// A RankingFunction weighs a word of an entry's English definitions. The
+// weights of the words of an English search are added up to rank the
+// entries that have them.
+type RankingFunction func(t TermStats) float64
+
+// BM25 returns the Okapi BM25 ranking function. k1 sets how quickly
+// repeating a word stops adding to its weight, and b how much longer
+// definitions are penalized, from 0 for not at all to 1 for fully.
+func BM25(k1, b float64) RankingFunction {
+	return func(t TermStats) float64 {
+		n, total := float64(t.Entries), float64(t.Total)
+		idf := math.Log(1 + (total-n+0.5)/(n+0.5))
+
+		tf := float64(t.Count)
+		norm := 1 - b
+		if t.AvgLength > 0 {
+			norm += b * float64(t.Length) / t.AvgLength
+		}
+		return idf * tf * (k1 + 1) / (tf + k1*norm)
+	}
+}
+
+// DefaultRanking is BM25 with the parameters commonly used for it.
+var DefaultRanking = BM25(1.2, 0.75)
+
+// ShareRanking weighs a word by its share of the entry's definitions, which
+// is how the English index was scored before BM25. It ignores how common
+// the word is, so that "cat" weighs as much as "the".
+func ShareRanking(t TermStats) float64 {
+	if t.Length == 0 {
+		return 0
+	}
+	return float64(t.Count) / float64(t.Length)
+}
+
+// entryTerms returns the positions of the words of the English
+// definitions of e, along with the total number of words.
+func entryTerms(e Entry) (positions map[string][]Position, length int) {
+	positions = map[string][]Position{}
+	for i, g := range e.Glosses {
+		for j, w := range englishTerms(g.English) {
+			positions[w] = append(positions[w], Position{Gloss: i, Word: j})
+			length++
+		}
+	}
+	return positions, length
+}
+
+// SetRanking rebuilds the English index with the words of every entry
+// weighed by rank. LoadEntries uses DefaultRanking; other functions can be
+// set to compare rankings.
+func (d Dictionary) SetRanking(rank RankingFunction) {
+	// entry IDs run from 1 without gaps, so visiting them in order keeps
+	// the index the same from one load to the next
+	terms := make([]map[string][]Position, len(d.entries)+1)
+	lengths := make([]int, len(d.entries)+1)
+	entries := map[string]int{}
+	var total, sum int
+	for id := 1; id <= len(d.entries); id++ {
+		terms[id], lengths[id] = entryTerms(d.entries[EntryID(id)])
+		if lengths[id] == 0 {
+			continue
+		}
+		total++
+		sum += lengths[id]
+		for w := range terms[id] {
+			entries[w]++
+		}
+	}
+
+	var avg float64
+	if total > 0 {
+		avg = float64(sum) / float64(total)
+	}
+
+	*d.english = *NewInvertedIndex()
+	for id := 1; id < len(terms); id++ {
+		for w, positions := range terms[id] {
+			score := rank(TermStats{
+				Count:     len(positions),
+				Length:    lengths[id],
+				AvgLength: avg,
+				Entries:   entries[w],
+				Total:     total,
+			})
+			d.english.Insert(w, EntryID(id), score, positions...)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package dictionary
+
+import (
+	"reflect"
+	"testing"
+)
+
+var rankingLines = []string{
+	"猫舌 [ねこじた] /(n) aversion to hot food/person who dislikes hot food (like a cat)/EntL1467660X/",
+	"招き猫 [まねきねこ] /(n) beckoning cat (figurine of a cat with a raised paw)/EntL1535860X/",
+	"猫 [ねこ] /(n) cat/(P)/EntL1467640X/",
+	"猫背 [ねこぜ] /(n) stoop/hunchback/slouch (like a cat)/EntL1467690X/",
+	"熱い [あつい] /(adj-i) hot (to the touch)/(P)/EntL1431730X/",
+	"食べ物 [たべもの] /(n) food/(P)/EntL1402310X/",
+	"本 [ほん] /(n) book/a volume/(P)/EntL1522150X/",
+	"一つ [ひとつ] /(n) one/a single thing/(P)/EntL1160790X/",
+	"或る [ある] /(adj-pn,uk) a certain/some/(P)/EntL1586840X/",
+	"一人 [ひとり] /(n) one person/a single person/(P)/EntL1576150X/",
+	"少し [すこし] /(adv) a little/a few/(P)/EntL1348910X/",
+}
+
+var bm25Tests = []struct {
+	search   string
+	minMatch int
+	first    string
+}{
+	{"cat", 0, "猫"},
+	{"a cat", 0, "猫"},
+	{"cat paw", 1, "招き猫"},
+	{"hot food", 0, "猫舌"},
+	{"hot food", 1, "猫舌"},
+	{"beckoning", 0, "招き猫"},
+	{"slouch", 0, "猫背"},
+}
+
+// English searches find the entries with all of their words unless a
+// minimum number of words to match is given
+func TestEnglishMinMatch(t *testing.T) {
+	d := loadText(t, rankingLines...)
+	for _, tt := range []struct {
+		search   string
+		minMatch int
+		want     int
+	}{
+		{"hunchback food", 0, 0},
+		{"hunchback food", 1, 3},
+		{"hunchback food", 2, 0},
+		{"hot food", 0, 1},
+		{"hot food", 1, 3},
+	} {
+		if got := d.englishMatches(tt.search, tt.minMatch, 10); len(got) != tt.want {
+			t.Errorf("englishMatches(%q, %d) found %d entries, want %d", tt.search, tt.minMatch, len(got), tt.want)
+		}
+	}
+}
+
+func TestBM25Ranking(t *testing.T) {
+	d := loadText(t, rankingLines...)
+	for _, tt := range bm25Tests {
+		got := d.englishMatches(tt.search, tt.minMatch, 10)
+		if len(got) == 0 {
+			t.Errorf("englishMatches(%q) returned no entries, want %q first", tt.search, tt.first)
+			continue
+		}
+		if got[0].Japanese != tt.first {
+			t.Errorf("englishMatches(%q)[0] = %q, want %q", tt.search, got[0].Japanese, tt.first)
+		}
+	}
+}
+
+func TestBM25(t *testing.T) {
+	stats := TermStats{Count: 1, Length: 4, AvgLength: 4, Entries: 10, Total: 1000}
+	base := DefaultRanking(stats)
+
+	longer := stats
+	longer.Length = 12
+	if w := DefaultRanking(longer); w >= base {
+		t.Errorf("weight in a longer definition = %v, want less than %v", w, base)
+	}
+
+	commoner := stats
+	commoner.Entries = 500
+	if w := DefaultRanking(commoner); w >= base {
+		t.Errorf("weight of a commoner word = %v, want less than %v", w, base)
+	}
+
+	repeated := stats
+	repeated.Count = 2
+	if w := DefaultRanking(repeated); w <= base || w >= 2*base {
+		t.Errorf("weight of a repeated word = %v, want between %v and %v", w, base, 2*base)
+	}
+}
+
+// weight returns the weight of w for the entry id in the English index.
+func weight(d Dictionary, w string, id EntryID) float64 {
+	for _, r := range d.english.Get(w) {
+		if r.id == id {
+			return r.score
+		}
+	}
+	return 0
+}
+
+func TestSetRanking(t *testing.T) {
+	d := loadText(t, rankingLines...)
+	nekojita := EntryID(1)
+
+	// by share of the definition, "a" weighs as much as "cat" does
+	d.SetRanking(ShareRanking)
+	if a, cat := weight(d, "a", nekojita), weight(d, "cat", nekojita); a != cat {
+		t.Errorf("ShareRanking weight of %q = %v, want %v as for %q", "a", a, cat, "cat")
+	}
+
+	d.SetRanking(DefaultRanking)
+	if a, cat := weight(d, "a", nekojita), weight(d, "cat", nekojita); a >= cat {
+		t.Errorf("DefaultRanking weight of %q = %v, want less than %v for %q", "a", a, cat, "cat")
+	}
+}
+
+func TestEnglishProximity(t *testing.T) {
+	d := loadText(t,
+		"心配 [しんぱい] /(n,vs) worry/care/concern/to take note of/(P)/EntL1360610X/",
+		"気を付ける [きをつける] /(exp,v1) to be careful/to take care/(P)/EntL1221270X/",
+		"世話 [せわ] /(n,vs) looking after/help/to take care of/(P)/EntL1376210X/",
+		"面倒を見る [めんどうをみる] /(exp,v1) to look after/to take good care of/EntL1613930X/",
+	)
+
+	for _, tt := range []struct {
+		search   string
+		minMatch int
+		want     []string
+		glosses  [][]int
+	}{
+		// stop words need not match, and "careful" has the term of "care"
+		{"to take care of", 0, []string{"世話", "気を付ける", "面倒を見る", "心配"}, [][]int{{2}, {1, 0}, {1, 0}, {3, 1}}},
+		{"take care", 0, []string{"気を付ける", "世話", "面倒を見る", "心配"}, [][]int{{1, 0}, {2}, {1}, {1, 3}}},
+	} {
+		got := d.englishMatches(tt.search, tt.minMatch, 10)
+		var words []string
+		var glosses [][]int
+		for _, e := range got {
+			words = append(words, e.Japanese)
+			glosses = append(glosses, e.MatchedGlosses)
+		}
+		if !reflect.DeepEqual(words, tt.want) {
+			t.Errorf("englishMatches(%q) = %q, want %q", tt.search, words, tt.want)
+		}
+		if !reflect.DeepEqual(glosses, tt.glosses) {
+			t.Errorf("englishMatches(%q) matched glosses %v, want %v", tt.search, glosses, tt.glosses)
+		}
+	}
+}
@@ -0,0 +1,413 @@
+package dictionary
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gojp/kana"
+)
+
+// hepburn lists kana and their modified Hepburn romaji. Where kana share a
+// romanization, as じ and ぢ do, romaji is read as the first of them.
+const hepburn = `
+あ a い i う u え e お o
+か ka き ki く ku け ke こ ko
+さ sa し shi す su せ se そ so
+た ta ち chi つ tsu て te と to
+な na に ni ぬ nu ね ne の no
+は ha ひ hi ふ fu へ he ほ ho
+ま ma み mi む mu め me も mo
+や ya ゆ yu よ yo
+ら ra り ri る ru れ re ろ ro
+わ wa ゐ i ゑ e を o ん n
+が ga ぎ gi ぐ gu げ ge ご go
+ざ za じ ji ず zu ぜ ze ぞ zo
+だ da ぢ ji づ zu で de ど do
+ば ba び bi ぶ bu べ be ぼ bo
+ぱ pa ぴ pi ぷ pu ぺ pe ぽ po
+ゔ vu
+きゃ kya きゅ kyu きょ kyo
+しゃ sha しゅ shu しぇ she しょ sho
+ちゃ cha ちゅ chu ちぇ che ちょ cho
+にゃ nya にゅ nyu にょ nyo
+ひゃ hya ひゅ hyu ひょ hyo
+みゃ mya みゅ myu みょ myo
+りゃ rya りゅ ryu りょ ryo
+ぎゃ gya ぎゅ gyu ぎょ gyo
+じゃ ja じゅ ju じぇ je じょ jo
+ぢゃ ja ぢゅ ju ぢょ jo
+びゃ bya びゅ byu びょ byo
+ぴゃ pya ぴゅ pyu ぴょ pyo
+ふぁ fa ふぃ fi ふぇ fe ふぉ fo
+ゔぁ va ゔぃ vi ゔぇ ve ゔぉ vo
+うぃ wi うぇ we うぉ wo
+てぃ ti でぃ di とぅ tu どぅ du つぁ tsa
+ぁ a ぃ i ぅ u ぇ e ぉ o ゃ ya ゅ yu ょ yo ゎ wa ゕ ka ゖ ke
+`
+
+// otherRomaji lists the spellings of Kunrei-shiki and Nihon-shiki that
+// differ from Hepburn, along with those typed with an IME, and their kana.
+const otherRomaji = `
+si し ti ち tu つ hu ふ zi じ di ぢ du づ
+sya しゃ syu しゅ syo しょ tya ちゃ tyu ちゅ tyo ちょ
+zya じゃ zyu じゅ zyo じょ dya ぢゃ dyu ぢゅ dyo ぢょ
+kwa くゎ wo を
+jya じゃ jyu じゅ jyo じょ cya ちゃ cyu ちゅ cyo ちょ dzu づ
+xa ぁ xi ぃ xu ぅ xe ぇ xo ぉ xya ゃ xyu ゅ xyo ょ xwa ゎ xtu っ xtsu っ
+la ぁ li ぃ lu ぅ le ぇ lo ぉ lya ゃ lyu ゅ lyo ょ ltu っ ltsu っ xn ん
+`
+
+// kanaRomaji maps kana to their Hepburn romaji, and romajiKana maps the
+// romaji of every system to kana. romajiContinuations maps the start of a
+// syllable, such as ky, to the kana it can become.
+var (
+	kanaRomaji          = map[string]string{}
+	romajiKana          = map[string]string{}
+	romajiContinuations = map[string][]string{}
+)
+
+func init() {
+	fields := strings.Fields(hepburn)
+	for i := 0; i < len(fields); i += 2 {
+		k, r := fields[i], fields[i+1]
+		kanaRomaji[k] = r
+		if _, ok := romajiKana[r]; !ok {
+			romajiKana[r] = k
+		}
+	}
+	fields = strings.Fields(otherRomaji)
+	for i := 0; i < len(fields); i += 2 {
+		romajiKana[fields[i]] = fields[i+1]
+	}
+
+	for r, k := range romajiKana {
+		for n := 1; n < len(r) && !isRomajiVowel(r[n-1]); n++ {
+			if !contains(romajiContinuations[r[:n]], k) {
+				romajiContinuations[r[:n]] = append(romajiContinuations[r[:n]], k)
+			}
+		}
+	}
+	for _, ks := range romajiContinuations {
+		sort.Strings(ks)
+	}
+}
+
+// maxRomajiSpellings bounds the number of kana spellings tried for a romaji
+// search with several long vowels.
+const maxRomajiSpellings = 8
+
+// longVowels maps vowels with macrons or circumflexes to the romaji they
+// stand for, most likely first: ō is usually おう, as in tōkyō, but can be
+// おお, as in ōkii.
+var longVowels = map[rune][]string{
+	'ā': {"aa"}, 'â': {"aa"},
+	'ī': {"ii"}, 'î': {"ii"},
+	'ū': {"uu"}, 'û': {"uu"},
+	'ē': {"ei", "ee"}, 'ê': {"ei", "ee"},
+	'ō': {"ou", "oo"}, 'ô': {"ou", "oo"},
+}
+
+// isRomaji reports whether s can be read as romaji: Latin letters, along
+// with apostrophes, as in kin'en, and hyphens for long vowels.
+func isRomaji(s string) bool {
+	return kana.IsLatin(strings.ReplaceAll(s, "'", ""))
+}
+
+// romajiCandidates returns the hiragana that the romaji s may stand for,
+// most likely first. Hepburn, Kunrei-shiki and Nihon-shiki are all read, as
+// are IME spellings such as nn, xtu and jya. Long vowels may be written
+// with macrons, as in tōkyō, or as oh, oo or ou, as in tohkyoh, each of
+// which is tried as both おう and おお.
+func romajiCandidates(s string) []string {
+	var candidates []string
+	for _, r := range romajiSpellings(s) {
+		if k := romajiToKana(r); !contains(candidates, k) {
+			candidates = append(candidates, k)
+		}
+	}
+	return candidates
+}
+
+// romajiSpellings spells out the long vowels of the romaji s in each of the
+// ways they might be written in kana, most likely first.
+func romajiSpellings(s string) []string {
+	s = strings.ToLower(s)
+	spellings := []string{""}
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		options := []string{string(r)}
+		if v, ok := longVowels[r]; ok {
+			options = v
+		} else if r == 'o' && strings.HasPrefix(s[i+1:], "o") {
+			options, size = []string{"oo", "ou"}, 2
+		} else if r == 'o' && strings.HasPrefix(s[i+1:], "h") && (i+2 == len(s) || !isRomajiVowel(s[i+2])) {
+			// oh before a consonant is a long o, as in ohno for 大野
+			options, size = []string{"ou", "oo"}, 2
+		}
+		i += size
+
+		if len(spellings)*len(options) > maxRomajiSpellings {
+			options = options[:1]
+		}
+		var next []string
+		for _, sp := range spellings {
+			for _, o := range options {
+				next = append(next, sp+o)
+			}
+		}
+		spellings = next
+	}
+	return spellings
+}
+
+// romajiPrefixes returns the hiragana that words starting with the romaji
+// s may start with, for searches that are still being typed. Unlike
+// romajiCandidates, a trailing incomplete syllable stands for every kana it
+// could become: kanj may be かんじ, and kan may be かん, or かな as in kana.
+// A final consonant may also be doubled, so kip may be きっ as in kippu.
+// Prefixes of other prefixes are left out, since they find the same words.
+func romajiPrefixes(s string) []string {
+	var prefixes []string
+	add := func(head string, continuations []string) {
+		if strings.IndexFunc(head, isASCIILetter) >= 0 {
+			return
+		}
+		for _, c := range continuations {
+			// a small kana alone, such as the っ of x or l, has no romaji
+			// and would find every reading
+			if romajiKey(Romanize(head+c)) == "" {
+				continue
+			}
+			if !contains(prefixes, head+c) {
+				prefixes = append(prefixes, head+c)
+			}
+		}
+	}
+	for _, sp := range romajiSpellings(s) {
+		k := romajiToKana(sp)
+		head := strings.TrimRightFunc(k, isASCIILetter)
+		if tail := k[len(head):]; tail != "" {
+			add(head, romajiContinuations[tail])
+			// a consonant may also be the first of a doubled one, as the
+			// t of zet may become ぜった or ぜって
+			r, _ := utf8.DecodeLastRuneInString(head)
+			if len(tail) == 1 && !strings.Contains("ny", tail) && len(romajiContinuations[tail]) > 0 && r != utf8.RuneError && r != 'ん' && r != 'っ' {
+				add(head, []string{"っ"})
+			}
+		} else {
+			add(k, []string{""})
+		}
+		// a final n, or ny, may start the next syllable rather than be ん
+		if i := strings.LastIndexByte(sp, 'n'); i >= 0 && (sp[i:] == "n" || sp[i:] == "ny") {
+			add(romajiToKana(sp[:i]), romajiContinuations[sp[i:]])
+		}
+	}
+
+	var shortest []string
+	for _, p := range prefixes {
+		covered := false
+		for _, q := range prefixes {
+			if q != p && strings.HasPrefix(p, q) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			shortest = append(shortest, p)
+		}
+	}
+	return shortest
+}
+
+func isASCIILetter(r rune) bool {
+	return r >= 'a' && r <= 'z'
+}
+
+func isRomajiVowel(c byte) bool {
+	return strings.IndexByte("aiueo", c) >= 0
+}
+
+// romajiToKana converts the lowercase romaji s to hiragana. n is ん before
+// consonants, at the end and when written nn or n', and m is ん before b, m
+// and p, as in shimbun. Doubled consonants, and the t of tch, are っ, and -
+// is ー. Anything else that is not romaji is kept as it is.
+func romajiToKana(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		var next byte
+		if i+1 < len(s) {
+			next = s[i+1]
+		}
+		switch c := s[i]; {
+		case c == 'n' && next == '\'':
+			b.WriteString("ん")
+			i += 2
+			continue
+		case c == 'n' && next == 'n' && i+2 == len(s):
+			b.WriteString("ん")
+			i += 2
+			continue
+		case c == 'n' && !isRomajiVowel(next) && next != 'y':
+			b.WriteString("ん")
+			i++
+			continue
+		case c == 'm' && (next == 'b' || next == 'm' || next == 'p'):
+			b.WriteString("ん")
+			i++
+			continue
+		case c >= 'a' && c <= 'z' && !isRomajiVowel(c) && (c == next || c == 't' && strings.HasPrefix(s[i+1:], "ch")):
+			b.WriteString("っ")
+			i++
+			continue
+		case c == '-':
+			b.WriteString("ー")
+			i++
+			continue
+		}
+
+		found := false
+		for n := min(4, len(s)-i); n > 0; n-- {
+			if k, ok := romajiKana[s[i:i+n]]; ok {
+				b.WriteString(k)
+				i += n
+				found = true
+				break
+			}
+		}
+		if !found {
+			_, size := utf8.DecodeRuneInString(s[i:])
+			b.WriteString(s[i : i+size])
+			i += size
+		}
+	}
+	return b.String()
+}
+
+// macrons maps vowels to their long forms in Hepburn.
+var macrons = map[rune]rune{'a': 'ā', 'i': 'ī', 'u': 'ū', 'e': 'ē', 'o': 'ō'}
+
+// Romanize writes the kana in s in modified Hepburn romaji, for readers who
+// do not read kana. Long vowels take macrons, as in tōkyō and kōhī, except
+// for ii and ei; ん is n' before vowels and y, as in kin'en; and っ doubles
+// the consonant after it. Anything that is not kana is kept as it is.
+func Romanize(s string) string {
+	var out []rune
+	runes := []rune(toHiragana(s))
+	var last rune // the vowel just written, if any
+	geminate, afterN := false, false
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		syllable, size := "", 1
+		if i+1 < len(runes) {
+			if rom, ok := kanaRomaji[string(runes[i:i+2])]; ok {
+				syllable, size = rom, 2
+			}
+		}
+		if syllable == "" {
+			syllable = kanaRomaji[string(r)]
+		}
+		i += size
+
+		switch {
+		case r == 'っ':
+			geminate = true
+			continue
+		case r == 'ー':
+			if _, ok := macrons[last]; ok {
+				out[len(out)-1] = macrons[last]
+				last = 0
+			}
+			continue
+		case syllable == "":
+			// a letter not yet converted still doubles after っ
+			if geminate && isASCIILetter(r) {
+				out = append(out, r)
+			}
+			out = append(out, r)
+			last, geminate, afterN = 0, false, false
+			continue
+		}
+
+		first := rune(syllable[0])
+		if afterN && (macrons[first] != 0 || first == 'y') {
+			out = append(out, '\'')
+		}
+		if len(syllable) == 1 && last != 0 && last != 'i' && (first == last || last == 'o' && first == 'u') {
+			out[len(out)-1] = macrons[last]
+			last, geminate, afterN = 0, false, false
+			continue
+		}
+		if geminate && macrons[first] == 0 {
+			if strings.HasPrefix(syllable, "ch") {
+				out = append(out, 't')
+			} else {
+				out = append(out, first)
+			}
+		}
+		out = append(out, []rune(syllable)...)
+
+		last = rune(syllable[len(syllable)-1])
+		if macrons[last] == 0 {
+			last = 0
+		}
+		geminate, afterN = false, r == 'ん'
+	}
+	return string(out)
+}
+
+// romajiKey folds romaji for the romaji tree, so that readings match however
+// their long vowels are written: macrons are dropped, and doubled vowels and
+// ou become a single vowel, so that tōkyō, toukyou and tokyo are all tokyo.
+// Hyphens are dropped too, but the apostrophe of n' is kept, so that kin'en
+// and kinen stay apart.
+func romajiKey(s string) string {
+	var b strings.Builder
+	var last rune
+	for _, r := range strings.ToLower(s) {
+		if plain, ok := plainVowels[r]; ok {
+			r = plain
+		}
+		switch {
+		case r == '-':
+			continue
+		case r < utf8.RuneSelf && isRomajiVowel(byte(r)) && (r == last || last == 'o' && r == 'u'):
+			continue
+		}
+		b.WriteRune(r)
+		last = r
+	}
+	return b.String()
+}
+
+// romajiKeys returns the keys of the romaji tree for the hiragana readings.
+// Readings with no romaji, such as ー, have no key, since the empty key is
+// a prefix of every reading.
+func romajiKeys(readings []string) []string {
+	var keys []string
+	for _, r := range readings {
+		if k := romajiKey(Romanize(r)); k != "" && !contains(keys, k) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// plainVowels maps vowels with macrons to plain vowels.
+var plainVowels = map[rune]rune{}
+
+func init() {
+	for v, m := range macrons {
+		plainVowels[m] = v
+	}
+}
+
+// entryRomaji writes the reading of e in romaji, keeping the final う of
+// verbs such as 思う (omou), which is not part of a long vowel.
+func entryRomaji(e Entry) string {
+	godanU := hasPos(e.Pos, func(p string) bool { return strings.HasPrefix(p, "v5u") })
+	if stem, ok := strings.CutSuffix(e.Furigana, "う"); ok && godanU {
+		return Romanize(stem) + "u"
+	}
+	return Romanize(e.Furigana)
+}
@@ -0,0 +1,274 @@
+package dictionary
+
+import (
+	"reflect"
+	"testing"
+)
+
+var romajiCandidateTests = []struct {
+	romaji string
+	want   []string
+}{
+	// Hepburn
+	{"chishiki", []string{"ちしき"}},
+	{"tsunami", []string{"つなみ"}},
+	{"fuji", []string{"ふじ"}},
+
+	// Kunrei-shiki and Nihon-shiki
+	{"tisiki", []string{"ちしき"}},
+	{"tunami", []string{"つなみ"}},
+	{"huzi", []string{"ふじ"}},
+	{"syasin", []string{"しゃしん"}},
+	{"hanadi", []string{"はなぢ"}},
+
+	// long vowels
+	{"tōkyō", []string{"とうきょう", "とうきょお", "とおきょう", "とおきょお"}},
+	{"TŌKYŌ", []string{"とうきょう", "とうきょお", "とおきょう", "とおきょお"}},
+	{"toukyou", []string{"とうきょう"}},
+	{"tohkyoh", []string{"とうきょう", "とうきょお", "とおきょう", "とおきょお"}},
+	{"ohayou", []string{"おはよう"}},
+	{"ookii", []string{"おおきい", "おうきい"}},
+	{"ōkii", []string{"おうきい", "おおきい"}},
+	{"sensē", []string{"せんせい", "せんせえ"}},
+	{"ra-men", []string{"らーめん"}},
+
+	// n
+	{"kin'en", []string{"きんえん"}},
+	{"kinen", []string{"きねん"}},
+	{"konnichiwa", []string{"こんにちわ"}},
+	{"konnyaku", []string{"こんにゃく"}},
+	{"honn", []string{"ほん"}},
+	{"shimbun", []string{"しんぶん"}},
+
+	// doubled consonants
+	{"gakkou", []string{"がっこう"}},
+	{"zasshi", []string{"ざっし"}},
+	{"matcha", []string{"まっちゃ"}},
+	{"kitte", []string{"きって"}},
+
+	// IME spellings
+	{"jya", []string{"じゃ"}},
+	{"xtu", []string{"っ"}},
+	{"wo", []string{"を"}},
+}
+
+func TestRomajiCandidates(t *testing.T) {
+	for _, tt := range romajiCandidateTests {
+		if got := romajiCandidates(tt.romaji); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("romajiCandidates(%q) = %q, want %q", tt.romaji, got, tt.want)
+		}
+	}
+}
+
+func TestRomajiCandidatesBounded(t *testing.T) {
+	if got := len(romajiCandidates("ōōōōōō")); got > maxRomajiSpellings {
+		t.Errorf("len(romajiCandidates(%q)) = %d, want at most %d", "ōōōōōō", got, maxRomajiSpellings)
+	}
+}
+
+var romajiPrefixTests = []struct {
+	romaji string
+	want   []string
+}{
+	{"kanji", []string{"かんじ"}},
+	{"kanj", []string{"かんじ"}},
+	{"kan", []string{"かん", "かな", "かに", "かぬ", "かね", "かの"}},
+	{"kany", []string{"かんや", "かんゆ", "かんよ", "かにゃ", "かにゅ", "かにょ"}},
+	{"ky", []string{"きゃ", "きゅ", "きょ"}},
+	{"sh", []string{"し"}},
+	{"ts", []string{"つ"}},
+	{"gakk", []string{"がっか", "がっき", "がっく", "がっけ", "がっこ"}},
+	{"kip", []string{"きぱ", "きぴ", "きぷ", "きぺ", "きぽ", "きっ"}},
+	{"kac", []string{"かち", "かっ"}},
+	{"t", []string{"た", "ち", "つ", "て", "と"}},
+	{"kaq", nil},
+}
+
+func TestRomajiPrefixes(t *testing.T) {
+	for _, tt := range romajiPrefixTests {
+		if got := romajiPrefixes(tt.romaji); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("romajiPrefixes(%q) = %q, want %q", tt.romaji, got, tt.want)
+		}
+	}
+}
+
+var romanizeTests = []struct {
+	kana string
+	want string
+}{
+	{"とうきょう", "tōkyō"},
+	{"おおきい", "ōkii"},
+	{"せんせい", "sensei"},
+	{"おかあさん", "okāsan"},
+	{"くうき", "kūki"},
+	{"ねえさん", "nēsan"},
+	{"コーヒー", "kōhī"},
+	{"ラーメン", "rāmen"},
+	{"ちしき", "chishiki"},
+	{"つなみ", "tsunami"},
+	{"ふじ", "fuji"},
+	{"しゃしん", "shashin"},
+	{"じゃあ", "jā"},
+	{"きんえん", "kin'en"},
+	{"こんやく", "kon'yaku"},
+	{"しんぶん", "shinbun"},
+	{"がっこう", "gakkō"},
+	{"まっちゃ", "matcha"},
+	{"ファイル", "fairu"},
+	{"ウィンドウ", "windō"},
+	// romaji not yet converted, as in a search being typed
+	{"きっp", "kipp"},
+	{"ＣＤ", "ＣＤ"},
+}
+
+func TestRomanize(t *testing.T) {
+	for _, tt := range romanizeTests {
+		if got := Romanize(tt.kana); got != tt.want {
+			t.Errorf("Romanize(%q) = %q, want %q", tt.kana, got, tt.want)
+		}
+	}
+}
+
+func TestEntryRomaji(t *testing.T) {
+	d := loadText(t,
+		"思う [おもう] /(v5u,vt) to think/(P)/EntL1589350X/",
+		"東京 [とうきょう] /(n) Tokyo/(P)/EntL1444140X/",
+	)
+	for id, want := range map[EntryID]string{1: "omou", 2: "tōkyō"} {
+		if got := d.entries[id].Romaji; got != want {
+			t.Errorf("romaji of %s = %q, want %q", d.entries[id].Japanese, got, want)
+		}
+	}
+}
+
+var romajiSearches = []struct {
+	s    string
+	want string
+}{
+	{"tisiki", "知識"},
+	{"chishiki", "知識"},
+	{"tōkyō", "東京"},
+	{"tohkyoh", "東京"},
+	{"tookyoo", "東京"},
+	{"kin'en", "禁煙"},
+	{"kinen", "記念"},
+	{"zassi", "雑誌"},
+}
+
+func TestSearchRomaji(t *testing.T) {
+	d := loadText(t,
+		"知識 [ちしき] /(n) knowledge/(P)/EntL1422320X/",
+		"東京 [とうきょう] /(n) Tokyo/(P)/EntL1444140X/",
+		"禁煙 [きんえん] /(n) no smoking/(P)/EntL1232610X/",
+		"記念 [きねん] /(n) commemoration/(P)/EntL1222710X/",
+		"雑誌 [ざっし] /(n) magazine/(P)/EntL1298880X/",
+	)
+	for _, tt := range romajiSearches {
+		entries := d.Search(tt.s, 10)
+		if len(entries) == 0 || entries[0].Japanese != tt.want {
+			var got []string
+			for _, e := range entries {
+				got = append(got, e.Japanese)
+			}
+			t.Errorf("d.Search(%q) = %q, want %q first", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestSearchPartialRomaji(t *testing.T) {
+	d := loadText(t,
+		"漢字 [かんじ] /(n) kanji/(P)/EntL1221890X/",
+		"仮名 [かな] /(n) kana/(P)/EntL1101710X/",
+		"感動 [かんどう] /(n) being deeply moved/(P)/EntL1216090X/",
+	)
+	tests := []struct {
+		s    string
+		want []string
+	}{
+		{"kanj", []string{"漢字"}},
+		// readings are walked in the romaji index, where kando comes up
+		// before kanji, and the longer prefix kana after both
+		{"kan", []string{"感動", "漢字", "仮名"}},
+	}
+	for _, tt := range tests {
+		r, err := d.SearchWithOptions(tt.s, SearchOptions{Partial: true, Sources: []SearchSource{SourceRomaji}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, e := range r.Entries {
+			got = append(got, e.Japanese)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("partial search for %q found %q, want %q", tt.s, got, tt.want)
+		}
+	}
+
+	d = loadText(t,
+		"絶対 [ぜったい] /(adv,n) absolutely/(P)/EntL1385000X/",
+		"切符 [きっぷ] /(n) ticket/(P)/EntL1383620X/",
+		"カップ /(n) cup/(P)/EntL1039200X/",
+	)
+	// a final consonant may be the first of a doubled one
+	for s, want := range map[string]string{"zet": "絶対", "kip": "切符", "kap": "カップ"} {
+		r, err := d.SearchWithOptions(s, SearchOptions{Partial: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(r.Entries) == 0 || r.Entries[0].Japanese != want || r.Entries[0].MatchedBy != SourceRomaji {
+			var got []string
+			for _, e := range r.Entries {
+				got = append(got, e.Japanese)
+			}
+			t.Errorf("partial search for %q found %q, want %q first", s, got, want)
+		}
+	}
+
+	d = loadText(t,
+		"切符 [きっぷ] /(n) ticket/(P)/EntL1383620X/",
+		"手 [て] /(n) hand/(P)/EntL1327190X/",
+		"愛 [あい] /(n) love/(P)/EntL1150410X/",
+	)
+	// a small kana typed alone only finds the readings it can start, and a
+	// doubled consonant finds the same words whether or not it is partial
+	for _, tt := range []struct {
+		s       string
+		partial bool
+		want    []string
+	}{
+		{"x", true, []string{"愛"}},
+		{"l", true, []string{"愛"}},
+		{"tt", true, nil},
+		{"tt", false, nil},
+		{"kipp", true, []string{"切符"}},
+		{"kipp", false, []string{"切符"}},
+	} {
+		r, err := d.SearchWithOptions(tt.s, SearchOptions{Partial: tt.partial, Sources: []SearchSource{SourceRomaji}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, e := range r.Entries {
+			got = append(got, e.Japanese)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("search for %q with partial %t found %q, want %q", tt.s, tt.partial, got, tt.want)
+		}
+	}
+}
+
+func TestSearchLongVowelMarkAlone(t *testing.T) {
+	d := loadText(t, searchLines...)
+	for _, s := range []string{"-", "ー"} {
+		for _, partial := range []bool{false, true} {
+			r, err := d.SearchWithOptions(s, SearchOptions{Partial: partial})
+			if err != nil {
+				t.Errorf("d.SearchWithOptions(%q) error = %v", s, err)
+				continue
+			}
+			if len(r.Entries) != 0 {
+				t.Errorf("search for %q with partial %t = %v, want none", s, partial, ids(r.Entries))
+			}
+		}
+	}
+}
@@ -0,0 +1,547 @@
+package dictionary
+
+import (
+	"container/heap"
+	"encoding/base64"
+	"errors"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// A SearchSource is one of the places Search looks for entries.
+type SearchSource string
+
+const (
+	// SourceJapanese matches the start of the Japanese of entries, and the
+	// dictionary forms of conjugated words
+	SourceJapanese SearchSource = "japanese"
+	// SourceFurigana matches the start of the readings of entries
+	SourceFurigana SearchSource = "furigana"
+	// SourceRomaji matches the start of the readings of entries, written
+	// in romaji, against Latin searches read as romaji
+	SourceRomaji SearchSource = "romaji"
+	// SourceEnglish matches the English definitions of entries
+	SourceEnglish SearchSource = "english"
+)
+
+// SearchSources lists every source, in the order their entries are ranked.
+var SearchSources = []SearchSource{SourceJapanese, SourceFurigana, SourceRomaji, SourceEnglish}
+
+const (
+	// defaultLimit is the number of entries returned when SearchOptions
+	// does not give a limit
+	defaultLimit = 10
+	// defaultQuota is the number of entries taken from a source that
+	// SearchOptions does not give a quota for
+	defaultQuota = 50
+)
+
+// SearchOptions controls which entries SearchWithOptions looks up, and
+// which page of them it returns.
+type SearchOptions struct {
+	// Limit is the maximum number of entries returned
+	Limit int
+	// Offset is the number of entries skipped. It must not be negative.
+	Offset int
+	// Cursor continues from the end of an earlier page, using the
+	// NextCursor of its results. It takes precedence over Offset.
+	Cursor string
+
+	// Quotas limits the number of entries taken from each source, so that
+	// no source crowds out the others. Sources without a quota take 50
+	// entries. Results can only be paged as far as the quotas allow.
+	Quotas map[SearchSource]int
+	// Sources lists the sources to search. All sources are searched if it
+	// is empty.
+	Sources []SearchSource
+
+	// MinShouldMatch is the number of words of an English search that an
+	// entry must have in its definitions. It is 1 to find entries with any
+	// of the words, and 0, the default, for entries with all of them.
+	MinShouldMatch int
+
+	// Explain asks for an Explanation of how a plain search was looked up
+	// in the English index, with the synonyms it was widened to.
+	Explain bool
+
+	// Partial marks a search that is still being typed, as with
+	// search-as-you-type. Romaji ending in an incomplete syllable, such as
+	// the j of kanj, then finds the words of every kana it could become.
+	Partial bool
+}
+
+// SearchResults is a page of entries found by SearchWithOptions.
+type SearchResults struct {
+	Entries []Entry
+	// Offset is the position of the first entry among all pages
+	Offset int
+	// Total is the number of entries found across all pages
+	Total int
+	// Truncated is set when a structured query was only checked against
+	// part of the dictionary, so that Total counts the entries found there
+	// and more may match
+	Truncated bool
+	// HasMore is set if there are entries after this page, which can be
+	// fetched with NextCursor
+	HasMore    bool
+	NextCursor string
+
+	// Explanation is set when SearchOptions asks for it
+	Explanation *Explanation
+}
+
+// ErrInvalidCursor is returned for a cursor that was not produced by
+// SearchWithOptions.
+var ErrInvalidCursor = errors.New("dictionary: invalid cursor")
+
+// ErrInvalidOffset is returned for a negative SearchOptions.Offset.
+var ErrInvalidOffset = errors.New("dictionary: invalid offset")
+
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	offset, err := strconv.Atoi(string(b))
+	if err != nil || offset < 0 {
+		return 0, ErrInvalidCursor
+	}
+	return offset, nil
+}
+
+func (o SearchOptions) searches(source SearchSource) bool {
+	if len(o.Sources) == 0 {
+		return true
+	}
+	for _, s := range o.Sources {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
+
+func (o SearchOptions) quota(source SearchSource) int {
+	if q, ok := o.Quotas[source]; ok {
+		return q
+	}
+	return defaultQuota
+}
+
+// SearchWithOptions searches for s, and returns the page of results that
+// opts asks for. Results are always in the same order for the same search,
+// so that pages do not overlap.
+//
+// A plain search looks up the sources in opts, taking at most the quota of
+// each. Common entries come first, and otherwise entries keep the order of
+// SearchSources. If that finds fewer than three entries, entries spelled
+// almost like s are added at the end; see FuzzySearch.
+//
+// A search with wildcards, such as 日? or *かん, only matches Japanese words
+// and readings; see IsWildcard. Searches for words ending in or containing
+// a string are answered from the substring index; see SearchSubstring. A
+// structured query is parsed by ParseQuery, and its *QueryError returned if
+// it is invalid. Sources and quotas only apply to plain searches, and a
+// search for punctuation alone finds nothing.
+func (d Dictionary) SearchWithOptions(s string, opts SearchOptions) (SearchResults, error) {
+	offset := opts.Offset
+	if offset < 0 {
+		return SearchResults{}, ErrInvalidOffset
+	}
+	if opts.Cursor != "" {
+		var err error
+		if offset, err = decodeCursor(opts.Cursor); err != nil {
+			return SearchResults{}, err
+		}
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+
+	// full-width letters and half-width katakana are searched as usual
+	s = norm.NFKC.String(s)
+	word := cleanWord(s)
+	var matches []Entry
+	var explanation *Explanation
+	complete := true
+	if IsStructuredQuery(s) {
+		q, err := ParseQuery(s)
+		if err != nil {
+			return SearchResults{}, err
+		}
+		matches, complete = d.query(q, math.MaxInt)
+	} else if strings.TrimSpace(word) == "" {
+		// punctuation alone leaves nothing to search for, and would
+		// otherwise match every entry
+	} else if entries, _, ok := d.SearchSubstring(word, 0, math.MaxInt); ok {
+		matches = entries
+	} else if IsWildcard(word) {
+		matches = d.searchWildcard(word, opts.quota(SourceJapanese)+opts.quota(SourceFurigana))
+		sort.SliceStable(matches, func(i, j int) bool {
+			return matches[i].Common && !matches[j].Common
+		})
+	} else {
+		matches = d.searchSources(s, word, opts)
+		if opts.Explain && opts.searches(SourceEnglish) {
+			e := d.ExplainEnglish(s)
+			explanation = &e
+		}
+	}
+
+	// every spelling of a word finds its canonical entry, listed once
+	matches = d.canonicalEntries(matches)
+
+	results := SearchResults{Entries: []Entry{}, Offset: offset, Total: len(matches), Truncated: !complete, Explanation: explanation}
+	if offset < len(matches) {
+		// offset+limit may overflow, but the entries left cannot
+		end := offset + min(limit, len(matches)-offset)
+		results.Entries = matches[offset:end]
+		if end < len(matches) {
+			results.HasMore = true
+			results.NextCursor = encodeCursor(end)
+		}
+	}
+	return results, nil
+}
+
+// matchTier ranks entries found by a plain search by how well they match,
+// before common entries are put first within each tier.
+type matchTier int
+
+const (
+	// tierWritten entries have the search as it is written
+	tierWritten matchTier = iota
+	// tierRomajiFolded entries have a reading that only matches romaji
+	// once long vowels are folded, such as おばさん for obaasan
+	tierRomajiFolded
+	// tierNormalized entries only match once normalized, such as ラーメン
+	// for らあめん
+	tierNormalized
+	// tierRomajiPrefix entries have a reading that romaji only starts, such
+	// as はなび for hana, which rank below English meanings
+	tierRomajiPrefix
+	// tierFuzzy entries are spelled almost like the search
+	tierFuzzy
+)
+
+// searchSources looks up word in the sources in opts, and returns every
+// entry found in ranked order, marked with the source that found it.
+// Japanese words and readings are looked up normalized, and romaji in the
+// romaji tree; see matchTier for how the entries they find are ranked.
+func (d Dictionary) searchSources(s, word string, opts SearchOptions) []Entry {
+	type ranked struct {
+		Entry
+		tier matchTier
+	}
+	var results []ranked
+	added := map[EntryID]bool{}
+
+	latin := isRomaji(word)
+	// romaji is looked up under the keys of the kana it may stand for, and
+	// while it is typed, of those it may become; only readings with the
+	// keys of the former match, and only those romanized as those kana
+	// match as written
+	var keys, exactKeys, written []string
+	if latin {
+		candidates := romajiCandidates(word)
+		for _, c := range candidates {
+			written = append(written, Romanize(c))
+		}
+		exactKeys = romajiKeys(candidates)
+		keys = exactKeys
+		if opts.Partial {
+			keys = romajiKeys(romajiPrefixes(word))
+		}
+	}
+	tier := func(e Entry, source SearchSource) matchTier {
+		switch {
+		case source == SourceEnglish || len(e.Inflections) > 0:
+			return tierWritten
+		case source == SourceRomaji:
+			switch {
+			case contains(written, e.Romaji) || contains(written, Romanize(e.Furigana)):
+				return tierWritten
+			case contains(exactKeys, romajiKey(e.Romaji)):
+				return tierRomajiFolded
+			}
+			return tierRomajiPrefix
+		case strings.HasPrefix(e.Japanese, word) || strings.HasPrefix(e.Furigana, word):
+			return tierWritten
+		}
+		return tierNormalized
+	}
+
+	add := func(entries []Entry, quota int, source SearchSource, fuzzy bool) {
+		n := 0
+		for _, e := range entries {
+			if n >= quota {
+				break
+			}
+			// some entries have the same Japanese and Furigana fields, so we
+			// should only add those to the results once
+			if added[e.ID] {
+				continue
+			}
+			added[e.ID] = true
+			e.MatchedBy = source
+			r := ranked{e, tierFuzzy}
+			if !fuzzy {
+				r.tier = tier(e, source)
+			}
+			results = append(results, r)
+			n++
+		}
+	}
+	lookup := func(ids []EntryID) []Entry {
+		var entries []Entry
+		for _, id := range ids {
+			entries = append(entries, d.entries[id])
+		}
+		return entries
+	}
+
+	key := normalizeKey(word)
+	if opts.searches(SourceJapanese) {
+		q := opts.quota(SourceJapanese)
+		// conjugated verbs and adjectives are not in the dictionary as they
+		// are, so look up their dictionary forms first
+		var entries []Entry
+		if !latin {
+			entries = d.Deinflect(word)
+		}
+		prefixed := lookup(d.japanese.FindWordsWithPrefix(key, q))
+		if len(prefixed) == 0 && !latin {
+			// words may be written with more or fewer okurigana than
+			// the dictionary has, as 取扱い for 取り扱い
+			prefixed = lookup(d.okuriganaEntries(word))
+		}
+		entries = append(entries, prefixed...)
+		add(entries, q, SourceJapanese, false)
+	}
+	if opts.searches(SourceFurigana) {
+		q := opts.quota(SourceFurigana)
+		add(lookup(d.furigana.FindWordsWithPrefix(key, q)), q, SourceFurigana, false)
+	}
+	if latin && opts.searches(SourceRomaji) {
+		q := opts.quota(SourceRomaji)
+		var entries []Entry
+		for _, hira := range romajiCandidates(word) {
+			entries = append(entries, d.Deinflect(hira)...)
+		}
+		for _, k := range keys {
+			entries = append(entries, lookup(d.romaji.FindWordsWithPrefix(k, q))...)
+		}
+		add(entries, q, SourceRomaji, false)
+	}
+	if opts.searches(SourceEnglish) {
+		q := opts.quota(SourceEnglish)
+		add(d.englishMatches(s, opts.MinShouldMatch, q), q, SourceEnglish, false)
+	}
+
+	// fall back to words that are spelled almost the same, which are less
+	// likely to be what the user meant, so they come last
+	japanese := opts.searches(SourceJapanese) || opts.searches(SourceFurigana) || opts.searches(SourceRomaji)
+	if len(results) < fuzzyMinResults && japanese {
+		source := SourceJapanese
+		if latin {
+			source = SourceRomaji
+		}
+		add(d.FuzzySearch(word, defaultQuota), defaultQuota, source, true)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.tier != b.tier {
+			return a.tier < b.tier
+		}
+		return a.Common && !b.Common
+	})
+
+	entries := make([]Entry, len(results))
+	for i, r := range results {
+		entries[i] = r.Entry
+	}
+	return entries
+}
+
+// exactBoost is how much more an entry scores when its glosses have all of
+// the words of an English search as they were written, rather than other
+// forms of them.
+const exactBoost = 0.5
+
+// phraseBoost is how much more an entry scores when one of its glosses has
+// all of the words of an English search next to each other.
+const phraseBoost = 2.0
+
+// englishMatches returns at most max entries whose English definitions have
+// at least minMatch of the words of s, or all of them if minMatch is 0, best
+// first. Words are matched by their terms, so that "ran" finds "to run", or
+// by the terms of their synonyms, and stop words are left out. An entry
+// scores the sum of the weights the ranking function gave its words in the
+// English index, boosted by how close together its best gloss has them, and
+// by how many of them it has as written. The glosses that have the words are
+// marked on the results, best first.
+func (d Dictionary) englishMatches(s string, minMatch, max int) []Entry {
+	// build a priority queue of relevant entries for english search terms,
+	// using our inverted index, and pull out the top ones
+	var words []string
+	for _, t := range searchTokens(s) {
+		// limit to 10 words, to keep the request time bounded
+		if len(words) < 10 && !contains(words, t.term) {
+			words = append(words, t.term)
+		}
+	}
+	if minMatch <= 0 {
+		minMatch = len(words)
+	}
+
+	// stop words do not need to match, but still count towards how close
+	// together the words are, as long as there are several words that do;
+	// a single word is no closer to a stop word in one gloss than another
+	var nearby []string
+	surfaces := map[string][]string{}
+	for _, t := range analyzeEnglish(s) {
+		near := len(words) > 1 || contains(words, t.term)
+		if near && len(nearby) < 10 && !contains(nearby, t.term) {
+			nearby = append(nearby, t.term)
+		}
+		surfaces[t.term] = append(surfaces[t.term], t.surface)
+	}
+
+	// words are widened to their synonyms, which weigh less
+	lists := make([][]Reference, len(words))
+	for k, w := range words {
+		synonyms := d.Synonyms(w)
+		if len(synonyms) == 0 {
+			lists[k] = d.english.Get(w)
+			continue
+		}
+		keys, weights := []string{w}, []float64{1}
+		for _, syn := range synonyms {
+			keys, weights = append(keys, syn), append(weights, synonymWeight)
+		}
+		lists[k] = d.english.Union(keys, weights)
+	}
+
+	matches := matchLists(lists, minMatch)
+	glosses := make(map[EntryID][]glossScore, len(matches))
+	pq := make(PriorityQueue, len(matches))
+	for i, r := range matches {
+		g, exact := d.glossProximity(nearby, surfaces, r.id)
+		glosses[r.id] = g
+		boost := 1 + exactBoost*float64(exact)/float64(len(nearby))
+		if len(g) > 0 {
+			boost *= 1 + g[0].score
+			if g[0].score == 1 && len(nearby) > 1 {
+				boost *= phraseBoost
+			}
+		}
+		pq[i] = &Item{
+			id:       r.id,
+			priority: r.score * boost,
+			index:    i,
+		}
+	}
+
+	heap.Init(&pq)
+	// Take the items out; they arrive in decreasing priority order.
+	var entries []Entry
+	for pq.Len() > 0 && len(entries) < max {
+		item := heap.Pop(&pq).(*Item)
+		e := d.entries[item.id]
+		for _, g := range glosses[item.id] {
+			e.MatchedGlosses = append(e.MatchedGlosses, g.gloss)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+type glossScore struct {
+	gloss int
+	score float64
+}
+
+// glossProximity scores how closely each gloss of the entry id has the terms
+// of a search, or their synonyms. A gloss with k of the n terms, all within
+// a window of w words, scores k/n * k/w, so that a gloss with all of the
+// terms next to each other scores 1. The glosses with any of the terms are
+// returned best first, along with the number of terms the entry has in one
+// of the surface forms that were searched for.
+func (d Dictionary) glossProximity(words []string, surfaces map[string][]string, id EntryID) (scores []glossScore, exact int) {
+	type hit struct{ word, pos int }
+	hits := map[int][]hit{}
+	tokens := map[int][]englishToken{}
+	for k, w := range words {
+		found := false
+		for _, key := range append([]string{w}, d.Synonyms(w)...) {
+			r, _ := d.english.Lookup(key, id)
+			for _, p := range r.positions {
+				hits[p.Gloss] = append(hits[p.Gloss], hit{word: k, pos: p.Word})
+				if key != w {
+					continue
+				}
+
+				if _, ok := tokens[p.Gloss]; !ok {
+					tokens[p.Gloss] = analyzeEnglish(d.entries[id].Glosses[p.Gloss].English)
+				}
+				if t := tokens[p.Gloss]; p.Word < len(t) && slices.Contains(surfaces[w], t[p.Word].surface) {
+					found = true
+				}
+			}
+		}
+		if found {
+			exact++
+		}
+	}
+
+	for gloss, h := range hits {
+		sort.Slice(h, func(i, j int) bool { return h[i].pos < h[j].pos })
+
+		k := 0
+		for i, x := range h {
+			if !slices.ContainsFunc(h[:i], func(y hit) bool { return y.word == x.word }) {
+				k++
+			}
+		}
+
+		// find the smallest window with all k words, moving its end forward
+		// and then its start as far as it still has them all
+		window := h[len(h)-1].pos - h[0].pos + 1
+		seen := map[int]int{}
+		start := 0
+		for _, x := range h {
+			seen[x.word]++
+			for len(seen) == k {
+				window = min(window, x.pos-h[start].pos+1)
+				if seen[h[start].word]--; seen[h[start].word] == 0 {
+					delete(seen, h[start].word)
+				}
+				start++
+			}
+		}
+
+		n := float64(len(words))
+		scores = append(scores, glossScore{
+			gloss: gloss,
+			score: float64(k) / n * float64(k) / float64(window),
+		})
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].score != scores[j].score {
+			return scores[i].score > scores[j].score
+		}
+		return scores[i].gloss < scores[j].gloss
+	})
+	return scores, exact
+}
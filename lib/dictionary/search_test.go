@@ -0,0 +1,136 @@
+package dictionary
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+var searchLines = []string{
+	"鼻 [はな] /(n) nose/(P)/EntL1490090X/",
+	"花 [はな] /(n) flower/(P)/EntL1194580X/",
+	"話 [はなし] /(n) talk/story/(P)/EntL1613840X/",
+	"花火 [はなび] /(n) fireworks/(P)/EntL1194690X/",
+	"花見 [はなみ] /(n) flower viewing/(P)/EntL1194610X/",
+	"花束 [はなたば] /(n) bouquet/EntL1194740X/",
+	"花びら [はなびら] /(n) flower petal/EntL1508190X/",
+	"離す [はなす] /(v5s,vt) to separate/(P)/EntL1557440X/",
+	"放す [はなす] /(v5s,vt) to release/(P)/EntL1511910X/",
+	"華 [はな] /(n) flower/beauty/EntL1194700X/",
+	"話す [はなす] /(v5s,vt) to speak/(P)/EntL1613830X/",
+}
+
+func ids(entries []Entry) []EntryID {
+	var ids []EntryID
+	for _, e := range entries {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func TestSearchLimit(t *testing.T) {
+	d := loadText(t, searchLines...)
+	for _, limit := range []int{1, 3, 5} {
+		if got := len(d.Search("はな", limit)); got != limit {
+			t.Errorf("len(d.Search(%q, %d)) = %d, want %d", "はな", limit, got, limit)
+		}
+	}
+}
+
+func TestSearchPaging(t *testing.T) {
+	d := loadText(t, searchLines...)
+
+	all, err := d.SearchWithOptions("はな", SearchOptions{Limit: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if all.HasMore || all.Total != len(all.Entries) {
+		t.Fatalf("all.HasMore = %t, all.Total = %d, want false, %d", all.HasMore, all.Total, len(all.Entries))
+	}
+
+	// pages fetched by offset and by cursor both add up to all entries
+	var byOffset, byCursor []Entry
+	cursor := ""
+	for page := 0; ; page++ {
+		r, err := d.SearchWithOptions("はな", SearchOptions{Limit: 3, Offset: page * 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		byOffset = append(byOffset, r.Entries...)
+		if !r.HasMore {
+			break
+		}
+	}
+	for {
+		r, err := d.SearchWithOptions("はな", SearchOptions{Limit: 3, Cursor: cursor})
+		if err != nil {
+			t.Fatal(err)
+		}
+		byCursor = append(byCursor, r.Entries...)
+		if !r.HasMore {
+			break
+		}
+		cursor = r.NextCursor
+	}
+
+	if !reflect.DeepEqual(ids(byOffset), ids(all.Entries)) {
+		t.Errorf("pages by offset = %v, want %v", ids(byOffset), ids(all.Entries))
+	}
+	if !reflect.DeepEqual(ids(byCursor), ids(all.Entries)) {
+		t.Errorf("pages by cursor = %v, want %v", ids(byCursor), ids(all.Entries))
+	}
+
+	// common entries come first
+	seenUncommon := false
+	for _, e := range all.Entries {
+		if !e.Common {
+			seenUncommon = true
+		} else if seenUncommon {
+			t.Errorf("common entry %s after an uncommon one", e.Japanese)
+		}
+	}
+
+	if _, err := d.SearchWithOptions("はな", SearchOptions{Cursor: "not a cursor"}); err != ErrInvalidCursor {
+		t.Errorf("d.SearchWithOptions with a bad cursor error = %v, want %v", err, ErrInvalidCursor)
+	}
+	if _, err := d.SearchWithOptions("はな", SearchOptions{Offset: -1}); err != ErrInvalidOffset {
+		t.Errorf("d.SearchWithOptions with a negative offset error = %v, want %v", err, ErrInvalidOffset)
+	}
+	if r, err := d.SearchWithOptions("はな", SearchOptions{Offset: 1, Limit: math.MaxInt}); err != nil || len(r.Entries) != len(all.Entries)-1 {
+		t.Errorf("d.SearchWithOptions with the largest limit = %d entries, %v, want %d", len(r.Entries), err, len(all.Entries)-1)
+	}
+}
+
+func TestSearchSources(t *testing.T) {
+	d := loadText(t, searchLines...)
+
+	r, err := d.SearchWithOptions("flower", SearchOptions{Sources: []SearchSource{SourceJapanese, SourceFurigana}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Entries) != 0 {
+		t.Errorf("search for %q without english = %v, want none", "flower", ids(r.Entries))
+	}
+
+	r, err = d.SearchWithOptions("hana", SearchOptions{Limit: 100, Quotas: map[SearchSource]int{SourceRomaji: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Total != 2 {
+		t.Errorf("search for %q with a romaji quota of 2 found %d entries, want %d", "hana", r.Total, 2)
+	}
+}
+
+func TestSearchPunctuation(t *testing.T) {
+	d := loadText(t, searchLines...)
+	for _, s := range []string{"…", "...", "!", "()", " . "} {
+		r, err := d.SearchWithOptions(s, SearchOptions{})
+		if err != nil {
+			t.Errorf("d.SearchWithOptions(%q) error = %v", s, err)
+			continue
+		}
+		if r.Total != 0 || len(r.Entries) != 0 {
+			t.Errorf("d.SearchWithOptions(%q) = %v, want none", s, ids(r.Entries))
+		}
+	}
+}
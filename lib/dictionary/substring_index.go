@@ -0,0 +1,182 @@
+package dictionary
+
+import (
+	"index/suffixarray"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// SubstringIndex finds the keys that end with or contain a string, using a
+// suffix array over all of its keys. Keys are inserted first, and Build is
+// called once before searching.
+type SubstringIndex struct {
+	keys   []string
+	ids    [][]EntryID
+	byKey  map[string]int // key -> index into keys
+	starts []int          // offset of every key in the indexed text
+	index  *suffixarray.Index
+}
+
+func NewSubstringIndex() *SubstringIndex {
+	return &SubstringIndex{
+		byKey: map[string]int{},
+	}
+}
+
+// Insert adds id under key.
+func (s *SubstringIndex) Insert(key string, id EntryID) {
+	if key == "" {
+		return
+	}
+	i, found := s.byKey[key]
+	if !found {
+		i = len(s.keys)
+		s.byKey[key] = i
+		s.keys = append(s.keys, key)
+		s.ids = append(s.ids, nil)
+	}
+	s.ids[i] = append(s.ids[i], id)
+}
+
+// Build indexes the inserted keys. Keys are separated by NUL bytes in the
+// indexed text, so that a key ending with a string is found by looking up
+// the string followed by NUL.
+func (s *SubstringIndex) Build() {
+	var b strings.Builder
+	s.starts = make([]int, len(s.keys))
+	for i, k := range s.keys {
+		b.WriteByte(0)
+		s.starts[i] = b.Len()
+		b.WriteString(k)
+	}
+	b.WriteByte(0)
+	s.index = suffixarray.New([]byte(b.String()))
+}
+
+// lookup returns the entries of every key in which sub occurs.
+func (s *SubstringIndex) lookup(sub string) []EntryID {
+	if s.index == nil || sub == "" {
+		return nil
+	}
+
+	var ids []EntryID
+	seen := map[int]bool{}
+	for _, offset := range s.index.Lookup([]byte(sub), -1) {
+		// the key is the last one that starts at or before offset
+		i := sort.SearchInts(s.starts, offset+1) - 1
+		if i < 0 || seen[i] {
+			continue
+		}
+		seen[i] = true
+		ids = append(ids, s.ids[i]...)
+	}
+	return ids
+}
+
+// EndsWith returns the entries of the keys that end with sub.
+func (s *SubstringIndex) EndsWith(sub string) []EntryID {
+	if sub == "" {
+		return nil
+	}
+	return s.lookup(sub + "\x00")
+}
+
+// Contains returns the entries of the keys that contain sub.
+func (s *SubstringIndex) Contains(sub string) []EntryID {
+	if strings.ContainsRune(sub, 0) {
+		return nil
+	}
+	return s.lookup(sub)
+}
+
+// substringQuery reports whether s asks for words ending in a string, as in
+// *的, or containing it, as in *電*, and returns that string.
+func substringQuery(s string) (sub string, contains bool, ok bool) {
+	s = compileWildcard(s).String()
+	if !strings.HasPrefix(s, "*") {
+		return "", false, false
+	}
+	sub = strings.TrimPrefix(s, "*")
+	sub, contains = strings.CutSuffix(sub, "*")
+	if sub == "" || IsWildcard(sub) {
+		return "", false, false
+	}
+	return sub, contains, true
+}
+
+// pageEntries orders the entries with the given IDs, common words first and
+// then shorter words first, and returns at most limit of them starting at
+// offset, along with their total number.
+func (d Dictionary) pageEntries(ids []EntryID, offset, limit int) ([]Entry, int) {
+	entries := []Entry{}
+	added := map[EntryID]bool{}
+	for _, id := range ids {
+		if added[id] {
+			continue
+		}
+		added[id] = true
+		entries = append(entries, d.entries[id])
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Common != b.Common {
+			return a.Common
+		}
+		if la, lb := utf8.RuneCountInString(a.Japanese), utf8.RuneCountInString(b.Japanese); la != lb {
+			return la < lb
+		}
+		return a.ID < b.ID
+	})
+
+	total := len(entries)
+	if offset >= total {
+		return []Entry{}, total
+	}
+	entries = entries[offset:]
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, total
+}
+
+// EndsWith returns the entries whose Japanese or reading ends with s. The
+// results are paged as described for SearchSubstring.
+func (d Dictionary) EndsWith(s string, offset, limit int) ([]Entry, int) {
+	return d.pageEntries(d.substrings.EndsWith(normalizeKey(s)), offset, limit)
+}
+
+// Contains returns the entries whose Japanese or reading contains s. The
+// results are paged as described for SearchSubstring.
+func (d Dictionary) Contains(s string, offset, limit int) ([]Entry, int) {
+	return d.pageEntries(d.substrings.Contains(normalizeKey(s)), offset, limit)
+}
+
+// SearchSubstring runs a search for words ending in a string, written as
+// *的, or containing it, written as *電*. Latin strings are read as romaji.
+// Common words come first, then shorter words; at most limit entries are
+// returned, starting at offset, along with the total number of matches. It
+// reports false if query is not such a search.
+func (d Dictionary) SearchSubstring(query string, offset, limit int) (results []Entry, total int, ok bool) {
+	sub, contains, ok := substringQuery(query)
+	if !ok {
+		return nil, 0, false
+	}
+
+	subs := []string{sub}
+	if isRomaji(sub) {
+		subs = romajiCandidates(sub)
+	}
+
+	var ids []EntryID
+	for _, s := range subs {
+		if contains {
+			ids = append(ids, d.substrings.Contains(normalizeKey(s))...)
+		} else {
+			ids = append(ids, d.substrings.EndsWith(normalizeKey(s))...)
+		}
+	}
+	results, total = d.pageEntries(ids, offset, limit)
+	return results, total, true
+}
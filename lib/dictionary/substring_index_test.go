@@ -0,0 +1,86 @@
+package dictionary
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestSubstringIndex(t *testing.T) {
+	s := NewSubstringIndex()
+	for i, entry := range getTests {
+		s.Insert(entry, EntryID(i))
+	}
+	s.Build()
+
+	for _, tt := range []struct {
+		name string
+		got  []EntryID
+		want string
+	}{
+		{"EndsWith(う)", s.EndsWith("う"), "てつだう ふつう 手伝う"},
+		{"EndsWith(つう)", s.EndsWith("つう"), "ふつう"},
+		{"EndsWith(通)", s.EndsWith("通"), "普通"},
+		{"EndsWith(ふ)", s.EndsWith("ふ"), ""},
+		{"Contains(つ)", s.Contains("つ"), "てつだう ふつ ふつう"},
+		{"Contains(伝)", s.Contains("伝"), "手伝う"},
+		{"Contains(しけん)", s.Contains("しけん"), "しけん"},
+		{"Contains(うし)", s.Contains("うし"), ""},
+	} {
+		var got []string
+		for _, id := range tt.got {
+			got = append(got, getTests[id])
+		}
+		sort.Strings(got)
+		if g := strings.Join(got, " "); g != tt.want {
+			t.Errorf("s.%s = %q, want %q", tt.name, g, tt.want)
+		}
+	}
+}
+
+func TestSearchSubstring(t *testing.T) {
+	d := loadText(t,
+		"電気 [でんき] /(n) electricity/(P)/EntL1431490X/",
+		"電話 [でんわ] /(n) telephone/(P)/EntL1432570X/",
+		"充電 [じゅうでん] /(n,vs) charging/EntL1332710X/",
+		"停電 [ていでん] /(n,vs) power outage/(P)/EntL1433530X/",
+		"電子メール [でんしメール] /(n) email/EntL1431960X/",
+		"積極的 [せっきょくてき] /(adj-na) positive/(P)/EntL1382580X/",
+		"的 [まと] /(n) target/(P)/EntL1460470X/",
+	)
+
+	for _, tt := range []struct {
+		query         string
+		offset, limit int
+		want          string
+		total         int
+	}{
+		{"*的", 0, 10, "的 積極的", 2},
+		{"*電", 0, 10, "停電 充電", 2},
+		{"*電*", 0, 10, "電気 電話 停電 充電 電子メール", 5},
+		{"*電*", 2, 2, "停電 充電", 5},
+		{"*電*", 10, 2, "", 5},
+		{"＊でん", 0, 10, "停電 充電", 2},
+		{"*den", 0, 10, "停電 充電", 2},
+		{"*shi*", 0, 10, "電子メール", 1},
+	} {
+		results, total, ok := d.SearchSubstring(tt.query, tt.offset, tt.limit)
+		if !ok {
+			t.Errorf("d.SearchSubstring(%q) ok = false, want true", tt.query)
+			continue
+		}
+		var got []string
+		for _, e := range results {
+			got = append(got, e.Japanese)
+		}
+		if g := strings.Join(got, " "); g != tt.want || total != tt.total {
+			t.Errorf("d.SearchSubstring(%q, %d, %d) = %q, %d, want %q, %d", tt.query, tt.offset, tt.limit, g, total, tt.want, tt.total)
+		}
+	}
+
+	for _, q := range []string{"電*", "*", "*電?", "電"} {
+		if _, _, ok := d.SearchSubstring(q, 0, 10); ok {
+			t.Errorf("d.SearchSubstring(%q) ok = true, want false", q)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package dictionary
+
+import (
+	"io"
+
+	"github.com/gojp/nihongo/synonyms"
+)
+
+// synonymWeight is the weight of a synonym of a word of an English search,
+// relative to the word itself.
+const synonymWeight = 0.5
+
+// LoadSynonyms reads a synonyms file, as described in the synonyms package,
+// and from then on widens English searches to the synonyms of their words,
+// at a lower weight. Synonyms are single words; longer phrases are skipped.
+// It can be called more than once, adding to the synonyms loaded before.
+func (d Dictionary) LoadSynonyms(r io.Reader) error {
+	f := synonyms.New(r)
+	for f.Scan() {
+		rule := f.Rule()
+		words := synonymTerms(rule.Words)
+		if rule.Expansions == nil {
+			for _, w := range words {
+				for _, s := range words {
+					d.addSynonym(w, s)
+				}
+			}
+			continue
+		}
+		for _, w := range words {
+			for _, s := range synonymTerms(rule.Expansions) {
+				d.addSynonym(w, s)
+			}
+		}
+	}
+	return f.Err()
+}
+
+// synonymTerms returns the terms of the single words among words.
+func synonymTerms(words []string) []string {
+	var terms []string
+	for _, w := range words {
+		if tokens := analyzeEnglish(w); len(tokens) == 1 {
+			terms = append(terms, tokens[0].term)
+		}
+	}
+	return terms
+}
+
+func (d Dictionary) addSynonym(term, synonym string) {
+	if term != synonym && !contains(d.synonyms[term], synonym) {
+		d.synonyms[term] = append(d.synonyms[term], synonym)
+	}
+}
+
+// Synonyms returns the terms that an English search for term is widened to.
+func (d Dictionary) Synonyms(term string) []string {
+	return d.synonyms[term]
+}
+
+// Explanation shows how an English search is looked up in the index.
+type Explanation struct {
+	Words []ExplainedWord `json:"words"`
+}
+
+// ExplainedWord is a word of an English search, with the terms it is looked
+// up under.
+type ExplainedWord struct {
+	// Word is the word as searched, in lowercase
+	Word string `json:"word"`
+	// Term is the stem or base form that Word is looked up under
+	Term string `json:"term"`
+	// Stop is set for stop words, which entries need not have
+	Stop bool `json:"stop,omitempty"`
+	// Synonyms are the terms also looked up for Word, which count for
+	// SynonymWeight times as much
+	Synonyms      []string `json:"synonyms,omitempty"`
+	SynonymWeight float64  `json:"synonym_weight,omitempty"`
+}
+
+// ExplainEnglish explains how the English search s is looked up: the term
+// of each of its words, whether it is a stop word, and its synonyms.
+func (d Dictionary) ExplainEnglish(s string) Explanation {
+	searched := searchTokens(s)
+	e := Explanation{Words: []ExplainedWord{}}
+	for _, t := range analyzeEnglish(s) {
+		w := ExplainedWord{
+			Word:     t.surface,
+			Term:     t.term,
+			Stop:     !containsToken(searched, t),
+			Synonyms: d.Synonyms(t.term),
+		}
+		if len(w.Synonyms) > 0 {
+			w.SynonymWeight = synonymWeight
+		}
+		e.Words = append(e.Words, w)
+	}
+	return e
+}
+
+func containsToken(tokens []englishToken, t englishToken) bool {
+	for _, u := range tokens {
+		if u == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package dictionary
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var synonymLines = []string{
+	"自動車 [じどうしゃ] /(n) automobile/(P)/EntL1316030X/",
+	"車 [くるま] /(n) car/vehicle/(P)/EntL1344560X/",
+	"子猫 [こねこ] /(n) kitten/EntL1308340X/",
+	"猫 [ねこ] /(n) cat/(P)/EntL1467640X/",
+}
+
+var synonymRules = `# cars
+car, automobile, motor car
+kitty => kitten, cat
+`
+
+func TestSynonyms(t *testing.T) {
+	d := loadText(t, synonymLines...)
+	if err := d.LoadSynonyms(strings.NewReader(synonymRules)); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		search string
+		want   []string
+	}{
+		// the word as searched comes before its synonyms
+		{"car", []string{"車", "自動車"}},
+		{"automobiles", []string{"自動車", "車"}},
+		// one-way rules only widen the words on the left
+		{"kitty", []string{"子猫", "猫"}},
+		{"kitten", []string{"子猫"}},
+	} {
+		var got []string
+		for _, e := range d.englishMatches(tt.search, 0, 10) {
+			got = append(got, e.Japanese)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("englishMatches(%q) = %q, want %q", tt.search, got, tt.want)
+		}
+	}
+}
+
+func TestExplainEnglish(t *testing.T) {
+	d := loadText(t, synonymLines...)
+	if err := d.LoadSynonyms(strings.NewReader(synonymRules)); err != nil {
+		t.Fatal(err)
+	}
+	got := d.ExplainEnglish("the cars")
+	want := Explanation{Words: []ExplainedWord{
+		{Word: "the", Term: "the", Stop: true},
+		{Word: "cars", Term: "car", Synonyms: []string{"automobil"}, SynonymWeight: synonymWeight},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExplainEnglish(%q) = %+v, want %+v", "the cars", got, want)
+	}
+
+	r, err := d.SearchWithOptions("the cars", SearchOptions{Explain: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Explanation == nil || !reflect.DeepEqual(*r.Explanation, want) {
+		t.Errorf("SearchWithOptions(%q).Explanation = %+v, want %+v", "the cars", r.Explanation, want)
+	}
+}
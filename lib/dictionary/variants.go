@@ -0,0 +1,107 @@
+package dictionary
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// An EDICT2 line lists every spelling and reading of a word, as in
+// 取り扱い;取扱い;取扱 [とりあつかい], and is split into an entry for each of
+// them, all with the line's EntSeq. Searches return only the first of these,
+// the canonical entry, listing the other spellings and readings on it.
+
+// addVariant records e as a spelling of the word with its EntSeq, and
+// indexes it under its spelling without okurigana if it has any.
+func (d Dictionary) addVariant(e Entry) {
+	if e.EntSeq != "" {
+		d.variants[e.EntSeq] = append(d.variants[e.EntSeq], e.ID)
+	}
+	if key := okuriganaKey(e.Japanese); key != normalizeKey(e.Japanese) {
+		d.okurigana.Insert(key, e.ID)
+	}
+}
+
+// withVariants returns the canonical entry of the word e is a spelling of,
+// listing its other spellings and readings, and keeping how e was found.
+func (d Dictionary) withVariants(e Entry) Entry {
+	ids := d.variants[e.EntSeq]
+	if len(ids) == 0 {
+		return e
+	}
+	c := d.entries[ids[0]]
+	c.Inflections, c.MatchedGlosses, c.MatchedBy = e.Inflections, e.MatchedGlosses, e.MatchedBy
+	return d.listVariants(c)
+}
+
+// listVariants lists the spellings and readings of the word e is a spelling
+// of that differ from its own.
+func (d Dictionary) listVariants(e Entry) Entry {
+	e.AlsoWritten, e.AlsoRead = nil, nil
+	for _, id := range d.variants[e.EntSeq] {
+		v := d.entries[id]
+		if v.Japanese != e.Japanese && !contains(e.AlsoWritten, v.Japanese) {
+			e.AlsoWritten = append(e.AlsoWritten, v.Japanese)
+		}
+		if v.Furigana != e.Furigana && !contains(e.AlsoRead, v.Furigana) {
+			e.AlsoRead = append(e.AlsoRead, v.Furigana)
+		}
+	}
+	return e
+}
+
+// canonicalEntries replaces the entries by the canonical entries of their
+// words, keeping the first place each word was found at.
+func (d Dictionary) canonicalEntries(entries []Entry) []Entry {
+	canonical := []Entry{}
+	seen := map[EntryID]bool{}
+	for _, e := range entries {
+		c := d.withVariants(e)
+		if seen[c.ID] {
+			continue
+		}
+		seen[c.ID] = true
+		canonical = append(canonical, c)
+	}
+	return canonical
+}
+
+// okuriganaKey returns the normalized key of the Japanese word s without its
+// okurigana, the kana written after its kanji, so that 取り扱い, 取扱い and
+// 取扱 all have the key 取扱. Kana before the first kanji are kept, as in
+// お茶, and so are katakana.
+func okuriganaKey(s string) string {
+	var b strings.Builder
+	afterKanji := false
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) {
+			afterKanji = true
+		} else if afterKanji && unicode.Is(unicode.Hiragana, r) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return normalizeKey(b.String())
+}
+
+// okuriganaEntries returns the entries spelled as the Japanese word s once
+// okurigana are ignored, for when s is not in the dictionary as it is.
+func (d Dictionary) okuriganaEntries(s string) []EntryID {
+	if !strings.ContainsFunc(s, func(r rune) bool { return unicode.Is(unicode.Han, r) }) {
+		return nil
+	}
+	key := okuriganaKey(s)
+	return slices.Concat(d.okurigana.Get(key), d.japanese.Get(key))
+}
+
+// finalOkurigana returns the hiragana at the end of the Japanese word s,
+// after its last kanji.
+func finalOkurigana(s string) string {
+	i := strings.LastIndexFunc(s, func(r rune) bool { return unicode.Is(unicode.Han, r) })
+	if i < 0 {
+		return ""
+	}
+	_, size := utf8.DecodeRuneInString(s[i:])
+	return normalizeKey(s[i+size:])
+}
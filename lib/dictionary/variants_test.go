@@ -0,0 +1,109 @@
+package dictionary
+
+import (
+	"reflect"
+	"testing"
+)
+
+var okuriganaKeyTests = []struct {
+	word string
+	want string
+}{
+	{"取り扱い", "取扱"},
+	{"取扱い", "取扱"},
+	{"取扱", "取扱"},
+	{"申し込む", "申込"},
+	{"お茶", "お茶"},
+	{"ご飯", "ご飯"},
+	{"たべる", "たべる"},
+	{"テレビ局", "てれび局"},
+}
+
+func TestOkuriganaKey(t *testing.T) {
+	for _, tt := range okuriganaKeyTests {
+		if got := okuriganaKey(tt.word); got != tt.want {
+			t.Errorf("okuriganaKey(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestSearchVariants(t *testing.T) {
+	d := loadText(t,
+		"取り扱い;取扱い;取扱 [とりあつかい] /(n) handling/treatment/(P)/EntL1327990X/",
+		"日本;日本国 [にほん;にっぽん] /(n) Japan/(P)/EntL1582710X/",
+	)
+	tests := []struct {
+		s       string
+		want    string
+		written []string
+		read    []string
+	}{
+		{"取り扱い", "取り扱い", []string{"取扱い", "取扱"}, nil},
+		{"取扱い", "取り扱い", []string{"取扱い", "取扱"}, nil},
+		{"取扱", "取り扱い", []string{"取扱い", "取扱"}, nil},
+		{"とりあつかい", "取り扱い", []string{"取扱い", "取扱"}, nil},
+		{"handling", "取り扱い", []string{"取扱い", "取扱"}, nil},
+		{"日本国", "日本", []string{"日本国"}, []string{"にっぽん"}},
+		{"にっぽん", "日本", []string{"日本国"}, []string{"にっぽん"}},
+	}
+	for _, tt := range tests {
+		entries := d.Search(tt.s, 10)
+		if len(entries) != 1 {
+			var got []string
+			for _, e := range entries {
+				got = append(got, e.Japanese)
+			}
+			t.Errorf("d.Search(%q) = %q, want only %q", tt.s, got, tt.want)
+			continue
+		}
+		e := entries[0]
+		if e.Japanese != tt.want || !reflect.DeepEqual(e.AlsoWritten, tt.written) || !reflect.DeepEqual(e.AlsoRead, tt.read) {
+			t.Errorf("d.Search(%q) = %q also written %q and read %q, want %q also written %q and read %q",
+				tt.s, e.Japanese, e.AlsoWritten, e.AlsoRead, tt.want, tt.written, tt.read)
+		}
+	}
+}
+
+func TestGetVariants(t *testing.T) {
+	d := loadText(t, "取り扱い;取扱い;取扱 [とりあつかい] /(n) handling/treatment/(P)/EntL1327990X/")
+
+	e, found := d.Get(2)
+	if !found {
+		t.Fatalf("d.Get(2) found no entry")
+	}
+	if want := []string{"取り扱い", "取扱"}; e.Japanese != "取扱い" || !reflect.DeepEqual(e.AlsoWritten, want) {
+		t.Errorf("d.Get(2) = %q also written %q, want %q also written %q", e.Japanese, e.AlsoWritten, "取扱い", want)
+	}
+}
+
+func TestSearchOkurigana(t *testing.T) {
+	d := loadText(t,
+		"取り扱い [とりあつかい] /(n) handling/treatment/(P)/EntL1327990X/",
+		"申し込む [もうしこむ] /(v5m,vt) to apply for/(P)/EntL1374610X/",
+		"食べる [たべる] /(v1,vt) to eat/(P)/EntL1358280X/",
+	)
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"取扱い", "取り扱い"},
+		{"取扱", "取り扱い"},
+		{"申込む", "申し込む"},
+		{"申込んだ", "申し込む"},
+	}
+	for _, tt := range tests {
+		entries := d.Search(tt.s, 10)
+		if len(entries) == 0 || entries[0].Japanese != tt.want {
+			var got []string
+			for _, e := range entries {
+				got = append(got, e.Japanese)
+			}
+			t.Errorf("d.Search(%q) = %q, want %q first", tt.s, got, tt.want)
+		}
+	}
+
+	// the okurigana at the end of a word are inflected, so they must match
+	if results := d.Deinflect("食られた"); len(results) != 0 {
+		t.Errorf("d.Deinflect(%q) = %v, want no results", "食られた", results)
+	}
+}
@@ -0,0 +1,161 @@
+package dictionary
+
+import "strings"
+
+// maxPatternRunes bounds the length of wildcard patterns, so that the set of
+// positions in a pattern fits in a uint64.
+const maxPatternRunes = 63
+
+// IsWildcard reports whether s contains a wildcard: ? or ？ for exactly one
+// character, and * or ＊ for any run of characters.
+func IsWildcard(s string) bool {
+	return strings.ContainsAny(s, "?*？＊")
+}
+
+// wildcardPattern is a compiled wildcard pattern. Its states are sets of
+// positions in the pattern, as bits.
+type wildcardPattern []rune
+
+func compileWildcard(s string) wildcardPattern {
+	var p wildcardPattern
+	for _, r := range s {
+		switch r {
+		case '？':
+			r = '?'
+		case '＊':
+			r = '*'
+		}
+		// a run of stars matches the same as a single one
+		if r == '*' && len(p) > 0 && p[len(p)-1] == '*' {
+			continue
+		}
+		p = append(p, r)
+	}
+	return p
+}
+
+func (p wildcardPattern) String() string {
+	return string(p)
+}
+
+// closure adds to states the positions reachable by letting a star match
+// nothing.
+func (p wildcardPattern) closure(states uint64) uint64 {
+	for i, r := range p {
+		if r == '*' && states&(1<<i) != 0 {
+			states |= 1 << (i + 1)
+		}
+	}
+	return states
+}
+
+// step returns the states reached from states by matching the rune c.
+func (p wildcardPattern) step(states uint64, c rune) uint64 {
+	var next uint64
+	for i, r := range p {
+		if states&(1<<i) == 0 {
+			continue
+		}
+		switch r {
+		case '*':
+			next |= 1 << i
+		case '?', c:
+			next |= 1 << (i + 1)
+		}
+	}
+	return p.closure(next)
+}
+
+// accepts reports whether states include the end of the pattern.
+func (p wildcardPattern) accepts(states uint64) bool {
+	return states&(1<<len(p)) != 0
+}
+
+// FindWildcard returns at most max entries whose keys match the wildcard
+// pattern, walking the tree one character at a time and leaving branches
+// that can no longer match.
+func (r *RadixTree) FindWildcard(pattern string, max int) []EntryID {
+	p := compileWildcard(pattern)
+	if len(p) == 0 || len(p) > maxPatternRunes {
+		return nil
+	}
+
+	words := []EntryID{}
+	added := map[EntryID]bool{}
+
+	var walk func(n *RadixNode, partial string, states uint64)
+	walk = func(n *RadixNode, partial string, states uint64) {
+		if partial == "" && p.accepts(states) {
+			for _, id := range n.ids {
+				if len(words) >= max {
+					return
+				}
+				if !added[id] {
+					added[id] = true
+					words = append(words, id)
+				}
+			}
+		}
+
+		for _, e := range n.edges {
+			if len(words) >= max {
+				return
+			}
+			next := states
+			rest, ok := edgeRunes(partial, e.label, func(c rune) bool {
+				next = p.step(next, c)
+				return next != 0
+			})
+			if ok {
+				walk(e.target, rest, next)
+			}
+		}
+	}
+	walk(r.Root, "", p.closure(1))
+
+	return words
+}
+
+// searchWildcard looks up pattern, normalized, in the japanese and furigana
+// trees. Latin patterns are read as romaji.
+func (d Dictionary) searchWildcard(pattern string, limit int) []Entry {
+	patterns := []string{normalizeKey(pattern)}
+	if isRomaji(strings.NewReplacer("?", "", "*", "").Replace(pattern)) {
+		patterns = []string{normalizeKey(romajiWildcard(pattern))}
+	}
+
+	results := []Entry{}
+	added := map[EntryID]bool{}
+	for _, tree := range []*RadixTree{d.japanese, d.furigana} {
+		for _, p := range patterns {
+			for _, id := range tree.FindWildcard(p, limit-len(results)) {
+				if added[id] {
+					continue
+				}
+				added[id] = true
+				results = append(results, d.entries[id])
+			}
+		}
+	}
+	return results
+}
+
+// romajiWildcard converts the romaji between the wildcards in pattern to
+// kana, taking the most likely spelling of its long vowels.
+func romajiWildcard(pattern string) string {
+	convert := func(s string) string {
+		return romajiCandidates(s)[0]
+	}
+	var b strings.Builder
+	start := 0
+	for i, r := range pattern {
+		if r != '?' && r != '*' {
+			continue
+		}
+		b.WriteString(convert(pattern[start:i]))
+		b.WriteRune(r)
+		start = i + 1
+	}
+	b.WriteString(convert(pattern[start:]))
+	return b.String()
+}
@@ -0,0 +1,73 @@
+package dictionary
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+var wildcardTests = []struct {
+	pattern string
+	want    string
+}{
+	{"ふ?う", "ふつう"},
+	{"ふ*", "ふつ ふつう"},
+	{"*う", "てつだう ふつう 手伝う"},
+	{"*つ*", "てつだう ふつ ふつう"},
+	{"？通", "普通"},
+	{"手*う", "手伝う"},
+	{"ふ??", "ふつう"},
+	{"*", "しけん てつだう ふつ ふつう 手伝う 普通"},
+	{"?", ""},
+}
+
+func TestFindWildcard(t *testing.T) {
+	r := NewRadixTree()
+	for i, entry := range getTests {
+		r.Insert(entry, EntryID(i))
+	}
+
+	for _, tt := range wildcardTests {
+		var got []string
+		for _, id := range r.FindWildcard(tt.pattern, 10) {
+			got = append(got, getTests[id])
+		}
+		sort.Strings(got)
+		if g := strings.Join(got, " "); g != tt.want {
+			t.Errorf("r.FindWildcard(%q) = %q, want %q", tt.pattern, g, tt.want)
+		}
+	}
+
+	if got := len(r.FindWildcard("*", 2)); got != 2 {
+		t.Errorf("len(r.FindWildcard(%q, 2)) = %d, want %d", "*", got, 2)
+	}
+}
+
+func TestSearchWildcard(t *testing.T) {
+	d := loadText(t,
+		"日本 [にほん] /(n) Japan/(P)/EntL1582710X/",
+		"日光 [にっこう] /(n) sunlight/EntL1463200X/",
+		"漢字 [かんじ] /(n) kanji/(P)/EntL1315920X/",
+		"簡単 [かんたん] /(adj-na) simple/(P)/EntL1221220X/",
+	)
+
+	for _, tt := range []struct {
+		s    string
+		want string
+	}{
+		{"日?", "日光 日本"},
+		{"*かん", ""},
+		{"かん*", "漢字 簡単"},
+		{"kan*", "漢字 簡単"},
+		{"*ん", "日本 簡単"},
+	} {
+		var got []string
+		for _, e := range d.Search(tt.s, 10) {
+			got = append(got, e.Japanese)
+		}
+		sort.Strings(got)
+		if g := strings.Join(got, " "); g != tt.want {
+			t.Errorf("d.Search(%q) = %q, want %q", tt.s, g, tt.want)
+		}
+	}
+}
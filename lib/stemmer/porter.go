@@ -0,0 +1,294 @@
+// Package stemmer reduces English words to their stems with the Porter
+// stemming algorithm, so that "connected", "connecting" and "connection"
+// all become "connect".
+//
+// The algorithm is described in M.F. Porter, "An algorithm for suffix
+// stripping", Program 14(3), 1980. This is a port of his reference
+// implementation, including its later departures from the paper.
+package stemmer
+
+// Stem returns the stem of a lowercase English word. Words of two letters
+// or less, and words with characters other than a to z, are returned
+// unchanged.
+func Stem(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+
+	z := &stemmer{b: []byte(word), k: len(word) - 1}
+	z.step1ab()
+	if z.k > 0 {
+		z.step1c()
+		z.step2()
+		z.step3()
+		z.step4()
+		z.step5()
+	}
+	return string(z.b[:z.k+1])
+}
+
+// stemmer holds a word being stemmed. The word is b[:k+1], and j marks the
+// end of the stem before a suffix found by ends.
+type stemmer struct {
+	b []byte
+	k int
+	j int
+}
+
+// cons reports whether b[i] is a consonant. y is a consonant at the start
+// of a word and after a vowel.
+func (z *stemmer) cons(i int) bool {
+	switch z.b[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !z.cons(i-1)
+	}
+	return true
+}
+
+// m measures the number of consonant sequences in b[:j+1]. With c a run of
+// consonants and v a run of vowels, words of the form
+//
+//	[c][v]       give 0
+//	[c]vc[v]     give 1
+//	[c]vcvc[v]   give 2
+//
+// and so on.
+func (z *stemmer) m() int {
+	n := 0
+	i := 0
+	for {
+		if i > z.j {
+			return n
+		}
+		if !z.cons(i) {
+			break
+		}
+		i++
+	}
+	i++
+	for {
+		for {
+			if i > z.j {
+				return n
+			}
+			if z.cons(i) {
+				break
+			}
+			i++
+		}
+		i++
+		n++
+		for {
+			if i > z.j {
+				return n
+			}
+			if !z.cons(i) {
+				break
+			}
+			i++
+		}
+		i++
+	}
+}
+
+// vowelInStem reports whether b[:j+1] has a vowel.
+func (z *stemmer) vowelInStem() bool {
+	for i := 0; i <= z.j; i++ {
+		if !z.cons(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// doublec reports whether b[i-1:i+1] is a double consonant.
+func (z *stemmer) doublec(i int) bool {
+	return i >= 1 && z.b[i] == z.b[i-1] && z.cons(i)
+}
+
+// cvc reports whether b[i-2:i+1] is consonant, vowel, consonant, and the
+// last consonant is not w, x or y. This is used when restoring an e, as in
+// cav(e), lov(e), hop(e) and crim(e), but not snow, box or tray.
+func (z *stemmer) cvc(i int) bool {
+	if i < 2 || !z.cons(i) || z.cons(i-1) || !z.cons(i-2) {
+		return false
+	}
+	switch z.b[i] {
+	case 'w', 'x', 'y':
+		return false
+	}
+	return true
+}
+
+// ends reports whether the word ends with s, and if so sets j to the end
+// of the stem before it.
+func (z *stemmer) ends(s string) bool {
+	if len(s) > z.k+1 || string(z.b[z.k+1-len(s):z.k+1]) != s {
+		return false
+	}
+	z.j = z.k - len(s)
+	return true
+}
+
+// setTo replaces the suffix after j with s.
+func (z *stemmer) setTo(s string) {
+	z.b = append(z.b[:z.j+1], s...)
+	z.k = z.j + len(s)
+}
+
+// replace replaces the suffix after j with s if the stem has a consonant
+// sequence.
+func (z *stemmer) replace(s string) {
+	if z.m() > 0 {
+		z.setTo(s)
+	}
+}
+
+// replaceFirst replaces the first of suffixes the word ends with by its
+// replacement. Suffixes come in pairs of suffix and replacement.
+func (z *stemmer) replaceFirst(suffixes ...string) {
+	for i := 0; i < len(suffixes); i += 2 {
+		if z.ends(suffixes[i]) {
+			z.replace(suffixes[i+1])
+			return
+		}
+	}
+}
+
+// step1ab removes plurals and -ed or -ing, as in
+//
+//	caresses  ->  caress
+//	ponies    ->  poni
+//	feed      ->  feed
+//	agreed    ->  agree
+//	matting   ->  mat
+//	mating    ->  mate
+//	meetings  ->  meet
+func (z *stemmer) step1ab() {
+	if z.b[z.k] == 's' {
+		if z.ends("sses") {
+			z.k -= 2
+		} else if z.ends("ies") {
+			z.setTo("i")
+		} else if z.b[z.k-1] != 's' {
+			z.k--
+		}
+	}
+
+	if z.ends("eed") {
+		if z.m() > 0 {
+			z.k--
+		}
+		return
+	}
+	if !(z.ends("ed") || z.ends("ing")) || !z.vowelInStem() {
+		return
+	}
+	z.k = z.j
+	switch {
+	case z.ends("at"):
+		z.setTo("ate")
+	case z.ends("bl"):
+		z.setTo("ble")
+	case z.ends("iz"):
+		z.setTo("ize")
+	case z.doublec(z.k):
+		switch z.b[z.k] {
+		case 'l', 's', 'z':
+		default:
+			z.k--
+		}
+	case z.m() == 1 && z.cvc(z.k):
+		z.setTo("e")
+	}
+}
+
+// step1c turns a final y into i when there is another vowel in the stem.
+func (z *stemmer) step1c() {
+	if z.ends("y") && z.vowelInStem() {
+		z.b[z.k] = 'i'
+	}
+}
+
+// step2 maps double suffixes to single ones, so that -ization, which is
+// -ize followed by -ation, becomes -ize.
+func (z *stemmer) step2() {
+	z.replaceFirst(
+		"ational", "ate",
+		"tional", "tion",
+		"enci", "ence",
+		"anci", "ance",
+		"izer", "ize",
+		"bli", "ble",
+		"alli", "al",
+		"entli", "ent",
+		"eli", "e",
+		"ousli", "ous",
+		"ization", "ize",
+		"ation", "ate",
+		"ator", "ate",
+		"alism", "al",
+		"iveness", "ive",
+		"fulness", "ful",
+		"ousness", "ous",
+		"aliti", "al",
+		"iviti", "ive",
+		"biliti", "ble",
+		"logi", "log",
+	)
+}
+
+// step3 deals with -ic-, -full, -ness and the like.
+func (z *stemmer) step3() {
+	z.replaceFirst(
+		"icate", "ic",
+		"ative", "",
+		"alize", "al",
+		"iciti", "ic",
+		"ical", "ic",
+		"ful", "",
+		"ness", "",
+	)
+}
+
+// step4 removes -ant, -ence and the like from stems with at least two
+// consonant sequences.
+func (z *stemmer) step4() {
+	for _, s := range []string{
+		"al", "ance", "ence", "er", "ic", "able", "ible", "ant", "ement",
+		"ment", "ent", "ion", "ou", "ism", "ate", "iti", "ous", "ive", "ize",
+	} {
+		if !z.ends(s) {
+			continue
+		}
+		// -ion is only removed after s or t, as in adoption
+		if s == "ion" && (z.j < 0 || (z.b[z.j] != 's' && z.b[z.j] != 't')) {
+			return
+		}
+		if z.m() > 1 {
+			z.k = z.j
+		}
+		return
+	}
+}
+
+// step5 removes a final -e, and turns -ll into -l, from stems with enough
+// consonant sequences.
+func (z *stemmer) step5() {
+	z.j = z.k
+	if z.b[z.k] == 'e' {
+		if a := z.m(); a > 1 || (a == 1 && !z.cvc(z.k-1)) {
+			z.k--
+		}
+	}
+	if z.b[z.k] == 'l' && z.doublec(z.k) && z.m() > 1 {
+		z.k--
+	}
+}
@@ -0,0 +1,71 @@
+package stemmer
+
+import "testing"
+
+var stemTests = []struct {
+	word string
+	want string
+}{
+	// step 1
+	{"caresses", "caress"},
+	{"ponies", "poni"},
+	{"ties", "ti"},
+	{"caress", "caress"},
+	{"cats", "cat"},
+	{"feed", "feed"},
+	{"agreed", "agre"},
+	{"plastered", "plaster"},
+	{"bled", "bled"},
+	{"motoring", "motor"},
+	{"sing", "sing"},
+	{"conflated", "conflat"},
+	{"troubled", "troubl"},
+	{"sized", "size"},
+	{"hopping", "hop"},
+	{"tanned", "tan"},
+	{"falling", "fall"},
+	{"hissing", "hiss"},
+	{"fizzed", "fizz"},
+	{"failing", "fail"},
+	{"filing", "file"},
+	{"happy", "happi"},
+	{"sky", "sky"},
+
+	// steps 2 to 5
+	{"relational", "relat"},
+	{"conditional", "condit"},
+	{"rational", "ration"},
+	{"digitizer", "digit"},
+	{"generalization", "gener"},
+	{"oscillator", "oscil"},
+	{"hopeful", "hope"},
+	{"goodness", "good"},
+	{"revival", "reviv"},
+	{"allowance", "allow"},
+	{"adjustment", "adjust"},
+	{"adoption", "adopt"},
+	{"probate", "probat"},
+	{"rate", "rate"},
+	{"cease", "ceas"},
+	{"controlling", "control"},
+	{"roll", "roll"},
+
+	{"running", "run"},
+	{"runs", "run"},
+	{"connected", "connect"},
+	{"connecting", "connect"},
+	{"connection", "connect"},
+
+	// left alone
+	{"is", "is"},
+	{"café", "café"},
+	{"b2b", "b2b"},
+}
+
+func TestStem(t *testing.T) {
+	for _, tt := range stemTests {
+		if got := Stem(tt.word); got != tt.want {
+			t.Errorf("Stem(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
@@ -7,12 +7,19 @@ import (
 	"flag"
 	"fmt"
 	"html/template"
+	"io"
 	"log"
 	"net/http"
+	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/gojp/nihongo/jmdict"
 	"github.com/gojp/nihongo/lib/dictionary"
+	"github.com/gojp/nihongo/tatoeba"
 	"github.com/golang/gddo/httputil/header"
 )
 
@@ -34,15 +41,140 @@ var (
 
 // Entry is a dictionary entry
 type Entry struct {
-	Word       string `json:"word"`
-	Furigana   string `json:"furigana"`
-	Definition string `json:"definition"`
-	Common     bool   `json:"common,omitempty"`
+	ID         dictionary.EntryID `json:"id"`
+	Word       string             `json:"word"`
+	Furigana   string             `json:"furigana"`
+	Romaji     string             `json:"romaji"`
+	Definition string             `json:"definition"`
+	Common     bool               `json:"common,omitempty"`
+
+	// Ruby splits Word into pieces with their part of Furigana
+	Ruby []dictionary.RubySegment `json:"ruby,omitempty"`
+
+	// AlsoWritten and AlsoRead are the other spellings and readings of
+	// Word, which searches for them find it by
+	AlsoWritten []string `json:"also_written,omitempty"`
+	AlsoRead    []string `json:"also_read,omitempty"`
+
+	// Inflection is the chain of inflections that lead from Word to the
+	// search text, such as "past + negative"
+	Inflection string `json:"inflection,omitempty"`
+
+	// Matched is the gloss of Definition that best matched an English
+	// search or quoted phrase
+	Matched string `json:"matched,omitempty"`
+
+	// MatchedBy is where a search found the entry: japanese, furigana or
+	// romaji for its word or reading, and english for its meaning
+	MatchedBy dictionary.SearchSource `json:"matched_by,omitempty"`
+
+	// Characters splits Word into its characters, marking the kanji that
+	// have a page of their own
+	Characters []Character `json:"characters"`
+
+	Examples []tatoeba.Sentence `json:"examples,omitempty"`
+
+	// Conjugates is set for verbs and adjectives, which have a
+	// conjugation table on their entry page
+	Conjugates bool `json:"conjugates,omitempty"`
+}
+
+// Character is a single character of an entry's word
+type Character struct {
+	Text string `json:"text"`
+	Link bool   `json:"link,omitempty"`
+}
+
+func newEntry(r dictionary.Entry) Entry {
+	var defs []string
+	for _, g := range r.Glosses {
+		defs = append(defs, g.English)
+	}
+
+	var matched string
+	if len(r.MatchedGlosses) > 0 {
+		matched = r.Glosses[r.MatchedGlosses[0]].English
+	}
+
+	var chars []Character
+	for _, c := range r.Japanese {
+		_, found := dict.Kanji(c)
+		chars = append(chars, Character{Text: string(c), Link: found})
+	}
+
+	return Entry{
+		ID:          r.ID,
+		Word:        r.Japanese,
+		Furigana:    r.Furigana,
+		Romaji:      r.Romaji,
+		Definition:  strings.Join(defs, "; "),
+		Ruby:        r.Ruby,
+		AlsoWritten: r.AlsoWritten,
+		AlsoRead:    r.AlsoRead,
+		Common:      r.Common,
+		Inflection:  strings.Join(r.Inflections, " + "),
+		Matched:     matched,
+		MatchedBy:   r.MatchedBy,
+		Characters:  chars,
+		Examples:    dict.Examples(r.ID, maxExamples),
+		Conjugates:  dictionary.Conjugate(r) != nil,
+	}
 }
 
 var dict dictionary.Dictionary
 
-func initialize() {
+// maxExamples is the maximum number of example sentences shown per entry
+var maxExamples int
+
+// dataFiles holds the paths of optional data files to load on startup.
+type dataFiles struct {
+	jmdict   string
+	kanjidic string
+	radkfile string
+	kradfile string
+	tatoeba  string
+	synonyms string
+}
+
+// rankings are the ranking functions of English searches that can be
+// chosen with -ranking.
+var rankings = map[string]dictionary.RankingFunction{
+	"bm25":  dictionary.DefaultRanking,
+	"share": dictionary.ShareRanking,
+}
+
+func initialize(files dataFiles) {
+	if files.jmdict != "" {
+		initializeJMdict(files.jmdict)
+	} else {
+		initializeEDict2()
+	}
+
+	loaders := []struct {
+		path string
+		name string
+		load func(io.Reader) error
+	}{
+		{files.kanjidic, "kanji", dict.LoadKanji},
+		{files.radkfile, "RADKFILE", dict.LoadRadkfile},
+		{files.kradfile, "KRADFILE", dict.LoadKradfile},
+		{files.tatoeba, "example sentences", dict.LoadExamples},
+		{files.synonyms, "synonyms", dict.LoadSynonyms},
+	}
+	for _, l := range loaders {
+		if l.path == "" {
+			continue
+		}
+		file, reader := openFile(l.path)
+		err := l.load(reader)
+		file.Close()
+		if err != nil {
+			log.Fatal("Could not load ", l.name, ": ", err)
+		}
+	}
+}
+
+func initializeEDict2() {
 	file, err := data.Open("data/edict2.json.gz")
 	if err != nil {
 		log.Fatal("Could not load edict2.json.gz: ", err)
@@ -60,11 +192,78 @@ func initialize() {
 	}
 }
 
+// initializeJMdict loads the dictionary from a JMdict XML file on disk.
+func initializeJMdict(path string) {
+	file, reader := openFile(path)
+	defer file.Close()
+
+	var err error
+	dict, err = dictionary.LoadEntries(jmdict.New(reader))
+	if err != nil {
+		log.Fatal("Could not load dictionary: ", err)
+	}
+}
+
+// openFile opens the file at path for reading, decompressing it if it is
+// gzipped. The caller must close the returned file.
+func openFile(path string) (*os.File, io.Reader) {
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatal("Could not open ", path, ": ", err)
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return file, file
+	}
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		log.Fatal("Could not create reader: ", err)
+	}
+	return file, reader
+}
+
+// KanjiMatch is a kanji found by a radical search
+type KanjiMatch struct {
+	Literal string `json:"literal"`
+	Strokes int    `json:"strokes,omitempty"`
+}
+
 type templateData struct {
 	Search  string  `json:"search"`
+	Mode    string  `json:"mode,omitempty"`
 	Entries []Entry `json:"entries"`
+
+	// paging of the results. Page is 1-based; NextCursor continues from
+	// the end of this page, as an alternative to NextPage.
+	Page       int    `json:"page"`
+	Total      int    `json:"total"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevPage   int    `json:"prev_page,omitempty"`
+	NextPage   int    `json:"next_page,omitempty"`
+	// Truncated is set when a query was only checked against part of the
+	// dictionary, so that more words than Total may match
+	Truncated bool `json:"truncated,omitempty"`
+
+	// Error describes why a structured query could not be parsed
+	Error *dictionary.QueryError `json:"error,omitempty"`
+
+	// Explain shows how an English search was looked up, when asked for
+	// with explain=1
+	Explain *dictionary.Explanation `json:"explain,omitempty"`
+
+	// results of a radical search
+	Kanji            []KanjiMatch `json:"kanji,omitempty"`
+	PossibleRadicals []string     `json:"possible_radicals,omitempty"`
 }
 
+// resultsPerPage is the number of entries shown per page of search results
+const resultsPerPage = 10
+
+// maxPage is the last page of search results that can be asked for, far
+// beyond any search's results, which keeps offsets from overflowing
+const maxPage = 1 << 20
+
 func homeHandler(w http.ResponseWriter, r *http.Request) {
 	// defer timeTrack(time.Now(), "/")
 
@@ -128,26 +327,85 @@ func search(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "/", http.StatusMovedPermanently)
 	}
 
-	// get the entries that match our text
-	entries := []Entry{}
-	results := dict.Search(text, 10)
-	for _, r := range results {
-		var defs []string
-		for _, g := range r.Glosses {
-			defs = append(defs, g.English)
-		}
-		entries = append(entries, Entry{
-			Word:       r.Japanese,
-			Furigana:   r.Furigana,
-			Definition: strings.Join(defs, "; "),
-			Common:     r.Common,
-		})
-	}
-
 	data := templateData{
 		Search:  text,
-		Entries: entries,
+		Mode:    r.Form.Get("mode"),
+		Entries: []Entry{},
+	}
+
+	switch data.Mode {
+	case "radicals":
+		// every character of the text is a radical the kanji must contain
+		radicals := []rune(strings.Join(strings.Fields(text), ""))
+		kanji, possible := dict.SearchRadicals(radicals)
+		for _, k := range kanji {
+			info, _ := dict.Kanji(k)
+			data.Kanji = append(data.Kanji, KanjiMatch{Literal: string(k), Strokes: info.StrokeCount})
+		}
+		for _, p := range possible {
+			data.PossibleRadicals = append(data.PossibleRadicals, string(p))
+		}
+	default:
+		page, err := strconv.Atoi(r.Form.Get("page"))
+		if err != nil || page < 1 {
+			page = 1
+		}
+		page = min(page, maxPage)
+		opts := dictionary.SearchOptions{
+			Limit:  resultsPerPage,
+			Offset: (page - 1) * resultsPerPage,
+			Cursor: r.Form.Get("cursor"),
+		}
+		if sources := r.Form.Get("sources"); sources != "" {
+			for _, s := range strings.Split(sources, ",") {
+				if !slices.Contains(dictionary.SearchSources, dictionary.SearchSource(s)) {
+					http.Error(w, fmt.Sprintf("unknown source %q, want one of %s", s, sourceNames()), http.StatusBadRequest)
+					return
+				}
+				opts.Sources = append(opts.Sources, dictionary.SearchSource(s))
+			}
+		}
+		opts.Explain = r.Form.Get("explain") != ""
+		// the instant search sends partial=1 while the search is typed
+		opts.Partial = r.Form.Get("partial") != ""
+		// English searches find entries with all of their words, unless
+		// match=any or a number of words is given
+		switch match := r.Form.Get("match"); match {
+		case "", "all":
+		case "any":
+			opts.MinShouldMatch = 1
+		default:
+			if opts.MinShouldMatch, err = strconv.Atoi(match); err != nil || opts.MinShouldMatch < 1 {
+				http.Error(w, "match must be all, any or a number of words", http.StatusBadRequest)
+				return
+			}
+		}
+
+		// get the entries that match our text
+		results, err := dict.SearchWithOptions(text, opts)
+		if qe, ok := err.(*dictionary.QueryError); ok {
+			data.Error = qe
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, r := range results.Entries {
+			data.Entries = append(data.Entries, newEntry(r))
+		}
+
+		// a cursor may start anywhere, so count pages from where it started
+		page = results.Offset/resultsPerPage + 1
+		data.Page, data.Total, data.Truncated = page, results.Total, results.Truncated
+		data.HasMore, data.NextCursor = results.HasMore, results.NextCursor
+		data.Explain = results.Explanation
+		if page > 1 {
+			data.PrevPage = page - 1
+		}
+		if results.HasMore {
+			data.NextPage = page + 1
+		}
 	}
+
 	jsonData, err := json.Marshal(data)
 	if err != nil {
 		log.Println("ERROR:", err)
@@ -155,19 +413,11 @@ func search(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	isXMLHTTP := r.Header.Get("X-Requested-With") == "XMLHttpRequest"
-	accepts := header.ParseAccept(r.Header, "Accept")
-	wantsJSON, wantsHTML := 0.0, 0.0
-	for _, acc := range accepts {
-		switch acc.Value {
-		case "text/json", "application/json":
-			wantsJSON = acc.Q
-		case "text/html":
-			wantsHTML = acc.Q
-		}
-	}
-	if isXMLHTTP || wantsJSON > wantsHTML {
+	if wantsJSON(r) {
 		w.Header().Set("Content-Type", "application/json")
+		if data.Error != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
 		b := []byte(template.HTML(string(jsonData)))
 		w.Write(b)
 
@@ -195,12 +445,272 @@ func search(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if data.Error != nil {
+		w.WriteHeader(http.StatusBadRequest)
+	}
 	err = t.ExecuteTemplate(w, "home.html", m)
 	if err != nil {
 		log.Println("ERROR:", err)
 	}
 }
 
+type entryData struct {
+	Entry        Entry                    `json:"entry"`
+	Conjugations []dictionary.Conjugation `json:"conjugations,omitempty"`
+}
+
+func entryHandler(w http.ResponseWriter, r *http.Request) {
+	defer timeTrack(time.Now(), r.URL.Path)
+
+	id, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, "/entry/"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	e, found := dict.Get(dictionary.EntryID(id))
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+
+	data := entryData{
+		Entry:        newEntry(e),
+		Conjugations: dictionary.Conjugate(e),
+	}
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		log.Println("ERROR:", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if wantsJSON(r) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(jsonData)
+
+		return
+	}
+
+	m := map[string]interface{}{
+		"data":        data,
+		"title":       e.Japanese + " in Japanese | " + title,
+		"description": fmt.Sprintf("%s (%s) - %s", data.Entry.Word, data.Entry.Furigana, data.Entry.Definition),
+	}
+
+	t, err := template.ParseFS(content, "templates/base.html", "templates/entry.html")
+	if err != nil {
+		log.Println("ERROR:", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = t.ExecuteTemplate(w, "entry.html", m)
+	if err != nil {
+		log.Println("ERROR:", err)
+	}
+}
+
+type analyzeData struct {
+	Text   string             `json:"text"`
+	Tokens []dictionary.Token `json:"tokens"`
+}
+
+func analyzeHandler(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	text := r.Form.Get("text")
+	if utf8.RuneCountInString(text) > dictionary.MaxAnalyzeRunes {
+		http.Error(w, fmt.Sprintf("text is longer than %d characters", dictionary.MaxAnalyzeRunes), http.StatusBadRequest)
+		return
+	}
+
+	defer timeTrack(time.Now(), "/analyze")
+
+	data := analyzeData{
+		Text:   text,
+		Tokens: dict.Analyze(text),
+	}
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		log.Println("ERROR:", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if wantsJSON(r) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(jsonData)
+
+		return
+	}
+
+	m := map[string]interface{}{
+		"data":        data,
+		"title":       "Sentence analyzer | " + title,
+		"description": "Split Japanese text into dictionary words",
+	}
+
+	t, err := template.ParseFS(content, "templates/base.html", "templates/analyze.html")
+	if err != nil {
+		log.Println("ERROR:", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = t.ExecuteTemplate(w, "analyze.html", m)
+	if err != nil {
+		log.Println("ERROR:", err)
+	}
+}
+
+type furiganaData struct {
+	Text     string                   `json:"text"`
+	Grade    int                      `json:"grade,omitempty"`
+	JLPT     int                      `json:"jlpt,omitempty"`
+	Segments []dictionary.RubySegment `json:"segments"`
+	HTML     string                   `json:"html"`
+	Brackets string                   `json:"brackets"`
+}
+
+func furiganaHandler(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	text := r.Form.Get("text")
+	if utf8.RuneCountInString(text) > dictionary.MaxAnalyzeRunes {
+		http.Error(w, fmt.Sprintf("text is longer than %d characters", dictionary.MaxAnalyzeRunes), http.StatusBadRequest)
+		return
+	}
+
+	var opts dictionary.FuriganaOptions
+	for name, v := range map[string]*int{"grade": &opts.Grade, "jlpt": &opts.JLPT} {
+		if s := r.Form.Get(name); s != "" {
+			if *v, err = strconv.Atoi(s); err != nil {
+				http.Error(w, "invalid "+name+": "+s, http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	defer timeTrack(time.Now(), "/furigana")
+
+	segments := dict.Furigana(text, opts)
+	data := furiganaData{
+		Text:     text,
+		Grade:    opts.Grade,
+		JLPT:     opts.JLPT,
+		Segments: segments,
+		HTML:     dictionary.RubyHTML(segments),
+		Brackets: dictionary.RubyBrackets(segments),
+	}
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		log.Println("ERROR:", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if wantsJSON(r) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(jsonData)
+
+		return
+	}
+
+	m := map[string]interface{}{
+		"data":        data,
+		"ruby":        template.HTML(data.HTML),
+		"title":       "Furigana | " + title,
+		"description": "Add furigana readings to Japanese text",
+	}
+
+	t, err := template.ParseFS(content, "templates/base.html", "templates/furigana.html")
+	if err != nil {
+		log.Println("ERROR:", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = t.ExecuteTemplate(w, "furigana.html", m)
+	if err != nil {
+		log.Println("ERROR:", err)
+	}
+}
+
+func kanjiHandler(w http.ResponseWriter, r *http.Request) {
+	defer timeTrack(time.Now(), r.URL.Path)
+
+	c, _ := utf8.DecodeRuneInString(strings.TrimPrefix(r.URL.Path, "/kanji/"))
+	k, found := dict.Kanji(c)
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+
+	jsonData, err := json.Marshal(k)
+	if err != nil {
+		log.Println("ERROR:", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if wantsJSON(r) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(jsonData)
+
+		return
+	}
+
+	m := map[string]interface{}{
+		"kanji":       k,
+		"title":       k.Literal + " kanji | " + title,
+		"description": fmt.Sprintf("%s - %s", k.Literal, strings.Join(k.Meanings, ", ")),
+	}
+
+	t, err := template.ParseFS(content, "templates/base.html", "templates/kanji.html")
+	if err != nil {
+		log.Println("ERROR:", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = t.ExecuteTemplate(w, "kanji.html", m)
+	if err != nil {
+		log.Println("ERROR:", err)
+	}
+}
+
+// sourceNames lists the names accepted by sources=, for error messages.
+func sourceNames() string {
+	names := make([]string, len(dictionary.SearchSources))
+	for i, s := range dictionary.SearchSources {
+		names[i] = string(s)
+	}
+	return strings.Join(names, ", ")
+}
+
+// wantsJSON reports whether the client asked for a JSON response rather
+// than an HTML page.
+func wantsJSON(r *http.Request) bool {
+	isXMLHTTP := r.Header.Get("X-Requested-With") == "XMLHttpRequest"
+	accepts := header.ParseAccept(r.Header, "Accept")
+	wantsJSON, wantsHTML := 0.0, 0.0
+	for _, acc := range accepts {
+		switch acc.Value {
+		case "text/json", "application/json":
+			wantsJSON = acc.Q
+		case "text/html":
+			wantsHTML = acc.Q
+		}
+	}
+	return isXMLHTTP || wantsJSON > wantsHTML
+}
+
 func aboutHandler(w http.ResponseWriter, r *http.Request) {
 	t, err := template.ParseFS(content, "templates/*.html")
 	if err != nil {
@@ -217,14 +727,36 @@ func aboutHandler(w http.ResponseWriter, r *http.Request) {
 
 func main() {
 	var addr string
+	var files dataFiles
+	var ranking string
 	flag.StringVar(&addr, "addr", "127.0.0.1:8080", "address to run on")
+	flag.StringVar(&files.jmdict, "jmdict", "", "load the dictionary from this JMdict XML file instead of the embedded EDICT2 data")
+	flag.StringVar(&files.kanjidic, "kanjidic", "", "load kanji information from this KANJIDIC2 XML file")
+	flag.StringVar(&files.radkfile, "radkfile", "", "load kanji components from this UTF-8 RADKFILE")
+	flag.StringVar(&files.kradfile, "kradfile", "", "load kanji components from this UTF-8 KRADFILE")
+	flag.StringVar(&files.tatoeba, "tatoeba", "", "load example sentences from this file of tab-separated Japanese/English sentence pairs")
+	flag.StringVar(&files.synonyms, "synonyms", "", "widen English searches with the synonyms in this file of comma-separated synonym sets")
+	flag.IntVar(&maxExamples, "examples", 3, "maximum number of example sentences shown per entry")
+	flag.StringVar(&ranking, "ranking", "bm25", "ranking of English searches: bm25, or share for the share of each definition a word makes up")
 	flag.Parse()
 
-	initialize()
+	rank, ok := rankings[ranking]
+	if !ok {
+		log.Fatal("Unknown ranking ", ranking)
+	}
+
+	initialize(files)
+	if ranking != "bm25" {
+		dict.SetRanking(rank)
+	}
 
 	http.HandleFunc("/", homeHandler)
 	http.HandleFunc("/search", search)
 	http.HandleFunc("/search/", search)
+	http.HandleFunc("/analyze", analyzeHandler)
+	http.HandleFunc("/furigana", furiganaHandler)
+	http.HandleFunc("/entry/", entryHandler)
+	http.HandleFunc("/kanji/", kanjiHandler)
 	http.HandleFunc("/about", aboutHandler)
 	http.Handle("/static/", http.FileServer(http.FS(static)))
 
@@ -0,0 +1,108 @@
+// Package radkfile reads the RADKFILE and KRADFILE kanji component files.
+// Both files must be converted from EUC-JP to UTF-8 before reading.
+package radkfile
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrMalformedLine is returned for a $ line without a single radical and a
+// stroke count, for kanji before the first $ line of a RADKFILE, and for a
+// KRADFILE line without the colon between a kanji and its radicals.
+var ErrMalformedLine = errors.New("radkfile: line is not a $ radical line, kanji, or kanji : radicals")
+
+// Radical is a component shared by a set of kanji.
+type Radical struct {
+	Radical rune
+	Strokes int
+	Kanji   []rune
+}
+
+func firstRune(s string) (rune, error) {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError || size != len(s) {
+		return 0, ErrMalformedLine
+	}
+	return r, nil
+}
+
+// ReadRadkfile reads a RADKFILE, which lists every radical followed by the
+// kanji that contain it:
+//
+//	$ 一 1
+//	亜唖娃阿哀愛挨姶逢葵茜穐悪握渥旭葦芦鯵梓圧斡扱宛姐虻飴絢綾鮎或粟袷安庵按暗案闇鞍杏
+func ReadRadkfile(r io.Reader) ([]Radical, error) {
+	var radicals []Radical
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "$") {
+			fields := strings.Fields(line)
+			if len(fields) < 3 {
+				return nil, ErrMalformedLine
+			}
+			rad, err := firstRune(fields[1])
+			if err != nil {
+				return nil, err
+			}
+			strokes, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return nil, ErrMalformedLine
+			}
+			radicals = append(radicals, Radical{Radical: rad, Strokes: strokes})
+			continue
+		}
+
+		if len(radicals) == 0 {
+			return nil, ErrMalformedLine
+		}
+		last := &radicals[len(radicals)-1]
+		last.Kanji = append(last.Kanji, []rune(line)...)
+	}
+
+	return radicals, s.Err()
+}
+
+// ReadKradfile reads a KRADFILE, which lists the radicals that make up each
+// kanji:
+//
+//	亜 : ｜ 一 口
+func ReadKradfile(r io.Reader) (map[rune][]rune, error) {
+	components := map[rune][]rune{}
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			return nil, ErrMalformedLine
+		}
+		kanji, err := firstRune(strings.TrimSpace(parts[0]))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range strings.Fields(parts[1]) {
+			rad, err := firstRune(f)
+			if err != nil {
+				return nil, err
+			}
+			components[kanji] = append(components[kanji], rad)
+		}
+	}
+
+	return components, s.Err()
+}
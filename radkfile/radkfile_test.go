@@ -0,0 +1,57 @@
+package radkfile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const radkfile = `# RADKFILE sample
+$ 一 1
+亜唖
+娃
+$ 化 2 js01
+化花
+`
+
+const kradfile = `# KRADFILE sample
+亜 : ｜ 一 口
+花 : 化 艾
+`
+
+func TestReadRadkfile(t *testing.T) {
+	got, err := ReadRadkfile(strings.NewReader(radkfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Radical{
+		{Radical: '一', Strokes: 1, Kanji: []rune("亜唖娃")},
+		{Radical: '化', Strokes: 2, Kanji: []rune("化花")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadRadkfile() = %v, want %v", got, want)
+	}
+}
+
+func TestReadKradfile(t *testing.T) {
+	got, err := ReadKradfile(strings.NewReader(kradfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[rune][]rune{
+		'亜': []rune("｜一口"),
+		'花': []rune("化艾"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadKradfile() = %v, want %v", got, want)
+	}
+}
+
+func TestReadMalformed(t *testing.T) {
+	if _, err := ReadRadkfile(strings.NewReader("亜唖\n")); err != ErrMalformedLine {
+		t.Errorf("ReadRadkfile() error = %v, want %v", err, ErrMalformedLine)
+	}
+	if _, err := ReadKradfile(strings.NewReader("亜 ｜ 一 口\n")); err != ErrMalformedLine {
+		t.Errorf("ReadKradfile() error = %v, want %v", err, ErrMalformedLine)
+	}
+}
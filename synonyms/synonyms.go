@@ -0,0 +1,98 @@
+// Package synonyms reads files of English synonyms, used to widen English
+// searches to words that mean the same.
+package synonyms
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrMalformedRule is returned for a set of synonyms with a single word, or
+// a one-way rule without words on both sides of =>.
+var ErrMalformedRule = errors.New("synonyms: rule needs two words, or words on both sides of =>")
+
+// Rule is a line of a synonyms file.
+type Rule struct {
+	// Words are synonyms of each other, or the words a one-way rule
+	// applies to
+	Words []string
+	// Expansions are the words that Words are widened to by a one-way
+	// rule. They are nil for a set of synonyms.
+	Expansions []string
+}
+
+// File reads synonym rules one line at a time. A line is either a set of
+// words that are all synonyms of each other, separated by commas,
+//
+//	car, automobile, motorcar
+//
+// or a one-way rule, where searching for the words on the left also finds
+// the words on the right, but not the other way around:
+//
+//	kitty => cat
+//
+// Blank lines and lines starting with # are skipped.
+type File struct {
+	*bufio.Scanner
+	rule *Rule
+	err  error
+}
+
+// New returns a File reading synonym rules from r.
+func New(r io.Reader) *File {
+	return &File{Scanner: bufio.NewScanner(r)}
+}
+
+// Scan reads the next rule, skipping blank lines and comments, and makes it
+// available through Rule. It stops at the first line that is not a rule.
+func (f *File) Scan() bool {
+	if f.err != nil {
+		return false
+	}
+	for f.Scanner.Scan() {
+		line := strings.TrimSpace(f.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		left, right, oneWay := strings.Cut(line, "=>")
+		rule := &Rule{Words: words(left)}
+		if oneWay {
+			rule.Expansions = words(right)
+		}
+		if (!oneWay && len(rule.Words) < 2) || (oneWay && (len(rule.Words) == 0 || len(rule.Expansions) == 0)) {
+			f.err = ErrMalformedRule
+			return false
+		}
+		f.rule = rule
+		return true
+	}
+	return false
+}
+
+// words splits a comma-separated list of words, leaving out empty ones.
+func words(list string) []string {
+	var words []string
+	for _, w := range strings.Split(list, ",") {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+// Rule returns the rule read by the last call to Scan.
+func (f *File) Rule() *Rule {
+	return f.rule
+}
+
+// Err returns ErrMalformedRule if Scan stopped at a line that is not a
+// rule, or the error that stopped reading the lines.
+func (f *File) Err() error {
+	if f.err != nil {
+		return f.err
+	}
+	return f.Scanner.Err()
+}
@@ -0,0 +1,44 @@
+package synonyms
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFile(t *testing.T) {
+	input := strings.Join([]string{
+		"# comment",
+		"car, Automobile,motorcar",
+		"",
+		"kitty, kitten => cat",
+	}, "\n")
+
+	f := New(strings.NewReader(input))
+	var got []Rule
+	for f.Scan() {
+		got = append(got, *f.Rule())
+	}
+	if err := f.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Rule{
+		{Words: []string{"car", "automobile", "motorcar"}},
+		{Words: []string{"kitty", "kitten"}, Expansions: []string{"cat"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFileMalformed(t *testing.T) {
+	for _, line := range []string{"car", "car, ", "=> cat", "kitty =>"} {
+		f := New(strings.NewReader(line + "\n"))
+		for f.Scan() {
+		}
+		if err := f.Err(); err != ErrMalformedRule {
+			t.Errorf("Err() for %q = %v, want %v", line, err, ErrMalformedRule)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// Package tatoeba reads Japanese/English sentence pairs exported from the
+// Tatoeba project.
+package tatoeba
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrColumns is returned for a line with neither of the column layouts that
+// Corpus reads.
+var ErrColumns = errors.New("tatoeba: line has neither 2 nor 4 tab-separated columns")
+
+// Sentence is a Japanese sentence with its English translation.
+type Sentence struct {
+	Japanese string `json:"japanese"`
+	English  string `json:"english"`
+}
+
+// Corpus reads sentence pairs one line at a time. Lines hold tab-separated
+// columns, either
+//
+//	japanese	english
+//
+// or, as in the Tatoeba sentence pairs export,
+//
+//	jpn_id	japanese	eng_id	english
+type Corpus struct {
+	*bufio.Scanner
+	sentence *Sentence
+	err      error
+}
+
+// New returns a Corpus reading sentence pairs from r.
+func New(r io.Reader) *Corpus {
+	return &Corpus{Scanner: bufio.NewScanner(r)}
+}
+
+// Scan reads the next sentence pair, skipping blank lines and # comments,
+// and makes it available through Sentence. It stops at the first line with
+// the wrong number of columns.
+func (c *Corpus) Scan() bool {
+	if c.err != nil {
+		return false
+	}
+	for c.Scanner.Scan() {
+		line := c.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		cols := strings.Split(line, "\t")
+		switch len(cols) {
+		case 2:
+			c.sentence = &Sentence{Japanese: cols[0], English: cols[1]}
+		case 4:
+			c.sentence = &Sentence{Japanese: cols[1], English: cols[3]}
+		default:
+			c.err = ErrColumns
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// Sentence returns the sentence pair read by the last call to Scan.
+func (c *Corpus) Sentence() *Sentence {
+	return c.sentence
+}
+
+// Err returns ErrColumns if Scan stopped at a line with the wrong number of
+// columns, or the error that stopped reading the lines.
+func (c *Corpus) Err() error {
+	if c.err != nil {
+		return c.err
+	}
+	return c.Scanner.Err()
+}
@@ -0,0 +1,42 @@
+package tatoeba
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCorpus(t *testing.T) {
+	input := strings.Join([]string{
+		"# comment",
+		"猫がいる。\tThere's a cat.",
+		"",
+		"4702\t日本に行きたい。\t1297\tI want to go to Japan.",
+	}, "\n")
+
+	c := New(strings.NewReader(input))
+	var got []Sentence
+	for c.Scan() {
+		got = append(got, *c.Sentence())
+	}
+	if err := c.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Sentence{
+		{"猫がいる。", "There's a cat."},
+		{"日本に行きたい。", "I want to go to Japan."},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCorpusMalformed(t *testing.T) {
+	c := New(strings.NewReader("a\tb\tc\n"))
+	for c.Scan() {
+	}
+	if err := c.Err(); err != ErrColumns {
+		t.Errorf("c.Err() = %v, want %v", err, ErrColumns)
+	}
+}